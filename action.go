@@ -75,7 +75,11 @@ func (ca *CompositeAction) Len() int {
 }
 
 func (ia *insertAction) Apply() {
-	ia.buffer.Insert(ia.point, string(ia.value))
+	// Not going through a string, as that would
+	// mangle runes that aren't valid Unicode
+	if len(ia.value) > 0 {
+		ia.buffer.InsertR(ia.point, ia.value)
+	}
 }
 
 func (ia *insertAction) Undo() {
@@ -84,7 +88,7 @@ func (ia *insertAction) Undo() {
 
 func (ea *eraseAction) Apply() {
 	ea.region = ea.region.Intersection(Region{0, ea.buffer.Size()})
-	ea.value = []rune(ea.buffer.Substr(ea.region))
+	ea.value = ea.buffer.SubstrR(ea.region)
 	ea.point = ea.region.Begin()
 	ea.insertAction.Undo()
 }
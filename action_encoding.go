@@ -0,0 +1,363 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type (
+	// The stable, buffer independent representation of an Action
+	// used by the JSON and binary encodings.
+	actionRecord struct {
+		Type string `json:"type"`
+		// Insert point
+		Point int `json:"point,omitempty"`
+		// Erased or replaced region
+		Region Region `json:"region"`
+		// Inserted data. Runes rather than a string, which
+		// would turn the escapes of undecodable bytes into U+FFFD.
+		Value []rune `json:"value,omitempty"`
+		// Data removed by an erase or replace, if the action has
+		// been applied
+		Erased  []rune         `json:"erased,omitempty"`
+		Actions []actionRecord `json:"actions,omitempty"`
+	}
+
+	// ActionJournal appends actions to a log, typically a file kept
+	// next to an open document, from which the edits can be recovered
+	// with ReplayJournal after a crash.
+	ActionJournal struct {
+		w    io.Writer
+		lock sync.Mutex
+	}
+)
+
+const (
+	recordInsert    = "insert"
+	recordErase     = "erase"
+	recordReplace   = "replace"
+	recordComposite = "composite"
+)
+
+// The largest journal record ReplayJournal accepts, so that
+// a corrupt length doesn't make it try to read gigabytes
+const maxJournalRecord = 1 << 30
+
+var (
+	ErrUnknownActionRecord = fmt.Errorf("Unknown action record type")
+	ErrJournalRecordSize   = fmt.Errorf("Journal record too large")
+)
+
+func newActionRecord(a Action) (actionRecord, error) {
+	switch t := a.(type) {
+	case *insertAction:
+		return actionRecord{Type: recordInsert, Point: t.point, Value: t.value}, nil
+	case *eraseAction:
+		return actionRecord{Type: recordErase, Region: t.region, Erased: t.value}, nil
+	case *CompositeAction:
+		if len(t.actions) == 2 {
+			// NewReplaceAction's erase followed by insert
+			ea, ok1 := t.actions[0].(*eraseAction)
+			ia, ok2 := t.actions[1].(*insertAction)
+			if ok1 && ok2 && ia.point == ea.region.Begin() {
+				return actionRecord{Type: recordReplace, Region: ea.region, Value: ia.value, Erased: ea.value}, nil
+			}
+		}
+		ret := actionRecord{Type: recordComposite}
+		for _, a2 := range t.actions {
+			r, err := newActionRecord(a2)
+			if err != nil {
+				return ret, err
+			}
+			ret.Actions = append(ret.Actions, r)
+		}
+		return ret, nil
+	}
+	return actionRecord{}, fmt.Errorf("Can't encode action of type %T", a)
+}
+
+// Returns the Action the record represents, operating on the given buffer
+func (r *actionRecord) action(b Buffer) (Action, error) {
+	switch r.Type {
+	case recordInsert:
+		return &insertAction{b, r.Point, r.Value}, nil
+	case recordErase:
+		return &eraseAction{insertAction{b, r.Region.Begin(), r.Erased}, r.Region}, nil
+	case recordReplace:
+		return &CompositeAction{[]Action{
+			&eraseAction{insertAction{b, r.Region.Begin(), r.Erased}, r.Region},
+			&insertAction{b, r.Region.Begin(), r.Value},
+		}}, nil
+	case recordComposite:
+		ca := &CompositeAction{}
+		for i := range r.Actions {
+			a, err := r.Actions[i].action(b)
+			if err != nil {
+				return nil, err
+			}
+			ca.Add(a)
+		}
+		return ca, nil
+	}
+	return nil, ErrUnknownActionRecord
+}
+
+// Returns the record undoing what this record does. Erase and
+// replace records can only be inverted once their action has
+// been applied, as the erased data isn't known before that.
+func (r *actionRecord) invert() actionRecord {
+	switch r.Type {
+	case recordInsert:
+		return actionRecord{Type: recordErase, Region: Region{r.Point, r.Point + len(r.Value)}, Erased: r.Value}
+	case recordErase:
+		return actionRecord{Type: recordInsert, Point: r.Region.Begin(), Value: r.Erased}
+	case recordReplace:
+		b := r.Region.Begin()
+		return actionRecord{Type: recordReplace, Region: Region{b, b + len(r.Value)}, Value: r.Erased, Erased: r.Value}
+	}
+	ret := actionRecord{Type: r.Type}
+	for i := len(r.Actions) - 1; i >= 0; i-- {
+		ret.Actions = append(ret.Actions, r.Actions[i].invert())
+	}
+	return ret
+}
+
+// MarshalAction returns the JSON encoding of the given action.
+//
+// Only actions created by this package, such as those returned by
+// NewInsertAction, NewEraseAction, NewReplaceAction and CompositeActions
+// made up of them, can be encoded.
+func MarshalAction(a Action) ([]byte, error) {
+	r, err := newActionRecord(a)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&r)
+}
+
+// UnmarshalAction decodes the JSON encoded action, returning an
+// action operating on the given buffer.
+func UnmarshalAction(data []byte, b Buffer) (Action, error) {
+	var r actionRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return r.action(b)
+}
+
+// MarshalActionBinary returns the compact binary encoding of the given
+// action. See MarshalAction for which actions can be encoded.
+func MarshalActionBinary(a Action) ([]byte, error) {
+	r, err := newActionRecord(a)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	r.encode(&buf)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalActionBinary decodes the binary encoded action, returning
+// an action operating on the given buffer.
+func UnmarshalActionBinary(data []byte, b Buffer) (Action, error) {
+	rd := bytes.NewReader(data)
+	r, err := decodeActionRecord(rd)
+	if err != nil {
+		return nil, err
+	}
+	if rd.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes of trailing data after action", rd.Len())
+	}
+	return r.action(b)
+}
+
+// The binary encoding starts with the first byte of the record's type,
+// followed by the fields relevant for that type encoded as varints and
+// length prefixed runes, each rune a varint.
+func (r *actionRecord) encode(buf *bytes.Buffer) {
+	var tmp [binary.MaxVarintLen64]byte
+	putInt := func(i int) {
+		buf.Write(tmp[:binary.PutVarint(tmp[:], int64(i))])
+	}
+	putRunes := func(s []rune) {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(s)))])
+		for _, r := range s {
+			putInt(int(r))
+		}
+	}
+	buf.WriteByte(r.Type[0])
+	switch r.Type {
+	case recordInsert:
+		putInt(r.Point)
+		putRunes(r.Value)
+	case recordErase:
+		putInt(r.Region.A)
+		putInt(r.Region.B)
+		putRunes(r.Erased)
+	case recordReplace:
+		putInt(r.Region.A)
+		putInt(r.Region.B)
+		putRunes(r.Value)
+		putRunes(r.Erased)
+	case recordComposite:
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(r.Actions)))])
+		for i := range r.Actions {
+			r.Actions[i].encode(buf)
+		}
+	}
+}
+
+func decodeActionRecord(rd *bytes.Reader) (r actionRecord, err error) {
+	getInt := func() int {
+		var i int64
+		if err == nil {
+			i, err = binary.ReadVarint(rd)
+		}
+		return int(i)
+	}
+	getRunes := func() []rune {
+		if err != nil {
+			return nil
+		}
+		var l uint64
+		if l, err = binary.ReadUvarint(rd); err != nil {
+			return nil
+		} else if l > uint64(rd.Len()) {
+			// Each rune takes at least one byte
+			err = io.ErrUnexpectedEOF
+			return nil
+		}
+		s := make([]rune, l)
+		for i := range s {
+			s[i] = rune(getInt())
+		}
+		return s
+	}
+
+	t, err := rd.ReadByte()
+	if err != nil {
+		return r, err
+	}
+	switch t {
+	case recordInsert[0]:
+		r.Type = recordInsert
+		r.Point = getInt()
+		r.Value = getRunes()
+	case recordErase[0]:
+		r.Type = recordErase
+		r.Region.A = getInt()
+		r.Region.B = getInt()
+		r.Erased = getRunes()
+	case recordReplace[0]:
+		r.Type = recordReplace
+		r.Region.A = getInt()
+		r.Region.B = getInt()
+		r.Value = getRunes()
+		r.Erased = getRunes()
+	case recordComposite[0]:
+		r.Type = recordComposite
+		var l uint64
+		if l, err = binary.ReadUvarint(rd); err != nil {
+			return
+		}
+		for ; l > 0 && err == nil; l-- {
+			var r2 actionRecord
+			r2, err = decodeActionRecord(rd)
+			r.Actions = append(r.Actions, r2)
+		}
+	default:
+		err = ErrUnknownActionRecord
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// NewActionJournal returns a new ActionJournal appending to w
+func NewActionJournal(w io.Writer) *ActionJournal {
+	return &ActionJournal{w: w}
+}
+
+// Append logs that the given action has been applied. Erases and
+// replaces should be appended after being applied, so that the
+// data they removed is recorded too.
+func (j *ActionJournal) Append(a Action) error {
+	r, err := newActionRecord(a)
+	if err != nil {
+		return err
+	}
+	return j.write(&r)
+}
+
+// AppendUndo logs that the given action has been undone
+func (j *ActionJournal) AppendUndo(a Action) error {
+	r, err := newActionRecord(a)
+	if err != nil {
+		return err
+	}
+	r = r.invert()
+	return j.write(&r)
+}
+
+// Each record is written prefixed with its length, in a single
+// write so that a crash leaves at most the last record truncated.
+func (j *ActionJournal) write(r *actionRecord) error {
+	var rec bytes.Buffer
+	r.encode(&rec)
+	if rec.Len() > maxJournalRecord {
+		return ErrJournalRecordSize
+	}
+
+	var tmp [binary.MaxVarintLen64]byte
+	buf := bytes.NewBuffer(tmp[:binary.PutUvarint(tmp[:], uint64(rec.Len()))])
+	rec.WriteTo(buf)
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+// ReplayJournal reads the journal from r, applying each logged action
+// to the given buffer in order. It returns the applied actions so that
+// they can be added to an undo history.
+//
+// If the journal ends with a truncated record, such as one being
+// written when the program crashed, the actions before it are still
+// applied and io.ErrUnexpectedEOF is returned. A record claiming to be
+// larger than any written by ActionJournal gives ErrJournalRecordSize.
+func ReplayJournal(r io.Reader, b Buffer) ([]Action, error) {
+	var actions []Action
+	br := bufio.NewReader(r)
+	for {
+		l, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return actions, nil
+		} else if err != nil {
+			return actions, io.ErrUnexpectedEOF
+		}
+		if l > maxJournalRecord {
+			return actions, ErrJournalRecordSize
+		}
+		data := make([]byte, l)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return actions, io.ErrUnexpectedEOF
+		}
+		a, err := UnmarshalActionBinary(data, b)
+		if err != nil {
+			return actions, err
+		}
+		a.Apply()
+		actions = append(actions, a)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestActionEncoding(t *testing.T) {
+	const init = "hello world"
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, init)
+
+	tests := []struct {
+		action   Action
+		expected string
+	}{
+		{NewInsertAction(b, 0, "𝄞€ŋ"), "𝄞€ŋhello world"},
+		{NewEraseAction(b, Region{3, 6}), "helworld"},
+		{NewEraseAction(b, Region{6, 3}), "helworld"},
+		{NewReplaceAction(b, Region{3, 6}, "vetica "), "helvetica world"},
+		{&CompositeAction{[]Action{
+			NewInsertAction(b, 11, "!"),
+			NewEraseAction(b, Region{0, 1}),
+			NewReplaceAction(b, Region{0, 0}, "H"),
+		}}, "Hello world!"},
+	}
+	type codec struct {
+		name      string
+		marshal   func(Action) ([]byte, error)
+		unmarshal func([]byte, Buffer) (Action, error)
+	}
+	for _, c := range []codec{
+		{"json", MarshalAction, UnmarshalAction},
+		{"binary", MarshalActionBinary, UnmarshalActionBinary},
+	} {
+		for i, test := range tests {
+			data, err := c.marshal(test.action)
+			if err != nil {
+				t.Fatalf("%s %d: %s", c.name, i, err)
+			}
+			a, err := c.unmarshal(data, b)
+			if err != nil {
+				t.Fatalf("%s %d: %s", c.name, i, err)
+			}
+			a.Apply()
+			if d := b.Substr(Region{0, b.Size()}); d != test.expected {
+				t.Errorf("%s Apply %d, Expected %q, but got %q", c.name, i, test.expected, d)
+			}
+			a.Undo()
+			if d := b.Substr(Region{0, b.Size()}); d != init {
+				t.Errorf("%s Undo %d, Expected %q, but got %q", c.name, i, init, d)
+			}
+		}
+	}
+
+	// Escaped undecodable bytes must survive, which they
+	// wouldn't if converted to a string on the way
+	for _, c := range []codec{
+		{"json", MarshalAction, UnmarshalAction},
+		{"binary", MarshalActionBinary, UnmarshalActionBinary},
+	} {
+		exp := []rune{'x', 0xdc80, 'y'}
+		data, err := c.marshal(&insertAction{b, 0, exp})
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		a, err := c.unmarshal(data, b)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		a.Apply()
+		if d := b.SubstrR(Region{0, 3}); !reflect.DeepEqual(d, exp) {
+			t.Errorf("%s: Expected %U, but got %U", c.name, exp, d)
+		}
+		a.Undo()
+	}
+
+	if _, err := UnmarshalAction([]byte(`{"type":"bogus"}`), b); err != ErrUnknownActionRecord {
+		t.Errorf("Expected %v, but got %v", ErrUnknownActionRecord, err)
+	}
+	if _, err := UnmarshalActionBinary([]byte{'i', 2, 10, 'a'}, b); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestActionJournal(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "hello world")
+
+	var journal bytes.Buffer
+	j := NewActionJournal(&journal)
+	for _, a := range []Action{
+		NewInsertAction(b, 11, "!"),
+		NewReplaceAction(b, Region{0, 5}, "goodbye"),
+		NewEraseAction(b, Region{7, 14}),
+	} {
+		a.Apply()
+		if err := j.Append(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := NewInsertAction(b, 0, "oops ")
+	a.Apply()
+	j.Append(a)
+	a.Undo()
+	j.AppendUndo(a)
+	const exp = "goodbye"
+	if d := b.Substr(Region{0, b.Size()}); d != exp {
+		t.Fatalf("Expected %q, but got %q", exp, d)
+	}
+
+	recovered := NewBuffer()
+	defer recovered.Close()
+	recovered.Insert(0, "hello world")
+	actions, err := ReplayJournal(bytes.NewReader(journal.Bytes()), recovered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 5 {
+		t.Errorf("Expected 5 actions, but got %d", len(actions))
+	}
+	if d := recovered.Substr(Region{0, recovered.Size()}); d != exp {
+		t.Errorf("Expected %q, but got %q", exp, d)
+	}
+
+	// A record cut short by a crash
+	recovered.Erase(0, recovered.Size())
+	recovered.Insert(0, "hello world")
+	actions, err = ReplayJournal(bytes.NewReader(journal.Bytes()[:journal.Len()-2]), recovered)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	}
+	if len(actions) != 4 {
+		t.Errorf("Expected 4 actions, but got %d", len(actions))
+	}
+	if d := recovered.Substr(Region{0, recovered.Size()}); d != "oops goodbye" {
+		t.Errorf("Expected %q, but got %q", "oops goodbye", d)
+	}
+
+	// A corrupt length
+	for _, test := range []struct {
+		data []byte
+		err  error
+	}{
+		{bytes.Repeat([]byte{0xff}, 9), io.ErrUnexpectedEOF},
+		{append(bytes.Repeat([]byte{0xff}, 9), 1), ErrJournalRecordSize},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x08}, ErrJournalRecordSize},
+	} {
+		if actions, err := ReplayJournal(bytes.NewReader(test.data), recovered); err != test.err {
+			t.Errorf("Expected %v, but got %v", test.err, err)
+		} else if len(actions) != 0 {
+			t.Errorf("Expected no actions, but got %d", len(actions))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sync"
+	"time"
+	"unicode"
+)
+
+type (
+	// ActionGrouper coalesces consecutive edits into CompositeAction
+	// groups, so that undoing typed text undoes a word at a time
+	// rather than a single character at a time.
+	//
+	// Consecutive inserts where each one continues at the end of the
+	// previous are put into the same group, as are consecutive erases
+	// where each one ends at the start of the previous (i.e. backspacing).
+	// A group is ended when:
+	//     - An edit of a different kind, or one that isn't adjacent
+	//       to the previous one, is added.
+	//     - Whitespace is typed or erased after non-whitespace.
+	//     - More time than the timeout has passed since the previous edit.
+	//     - #BreakGroup is called.
+	// Actions that are neither inserts nor erases always end up in a
+	// group of their own.
+	ActionGrouper struct {
+		commit  func(*CompositeAction)
+		timeout time.Duration
+		now     func() time.Time
+
+		group *CompositeAction
+		kind  groupKind
+		// The point the next edit has to be at to join the group
+		next int
+		// Whether the last rune inserted or erased was whitespace
+		space bool
+		last  time.Time
+		lock  sync.Mutex
+	}
+
+	groupKind int
+)
+
+const (
+	groupNone groupKind = iota
+	groupInsert
+	groupErase
+)
+
+// NewActionGrouper returns a new ActionGrouper calling commit with each
+// group once it's been ended. A timeout of 0 or less means that groups
+// are never ended because of the time between edits.
+func NewActionGrouper(timeout time.Duration, commit func(*CompositeAction)) *ActionGrouper {
+	return &ActionGrouper{
+		commit:  commit,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// Add adds the action to the current group, without first executing
+// the action, or ends the current group and starts a new one with it.
+func (g *ActionGrouper) Add(a Action) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.add(a)
+}
+
+// AddExec executes the provided action and then adds
+// the action to the grouper
+func (g *ActionGrouper) AddExec(a Action) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	a.Apply()
+	g.add(a)
+}
+
+// BreakGroup ends the current group, if any, and hands it to
+// the commit function.
+func (g *ActionGrouper) BreakGroup() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.flush()
+}
+
+func (g *ActionGrouper) flush() {
+	if g.group == nil {
+		return
+	}
+	ca := g.group
+	g.group = nil
+	g.kind = groupNone
+	g.commit(ca)
+}
+
+func (g *ActionGrouper) add(a Action) {
+	now := g.now()
+	expired := g.timeout > 0 && now.Sub(g.last) > g.timeout
+	g.last = now
+
+	var (
+		kind        groupKind
+		begin, next int
+		value       []rune
+	)
+	switch t := a.(type) {
+	case *insertAction:
+		kind, begin, next, value = groupInsert, t.point, t.point+len(t.value), t.value
+	case *eraseAction:
+		kind, begin, next, value = groupErase, t.region.End(), t.region.Begin(), t.value
+	default:
+		g.flush()
+		g.group = &CompositeAction{}
+		g.group.Add(a)
+		g.flush()
+		return
+	}
+
+	if g.group != nil {
+		join := !expired && kind == g.kind && begin == g.next
+		if join && len(value) > 0 {
+			// When erasing backwards, the rune adjacent to what has
+			// already been erased is the last one.
+			first := value[0]
+			if kind == groupErase {
+				first = value[len(value)-1]
+			}
+			join = !(unicode.IsSpace(first) && !g.space)
+		}
+		if !join {
+			g.flush()
+		}
+	}
+	if g.group == nil {
+		g.group = &CompositeAction{}
+		g.kind = kind
+	}
+	g.group.Add(a)
+	g.next = next
+	if len(value) > 0 {
+		last := value[len(value)-1]
+		if kind == groupErase {
+			last = value[0]
+		}
+		g.space = unicode.IsSpace(last)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActionGrouper(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	us := NewUndoStack(b, 0)
+
+	clock := time.Unix(0, 0)
+	g := NewActionGrouper(time.Second, func(ca *CompositeAction) { us.Add(ca) })
+	g.now = func() time.Time { return clock }
+
+	typ := func(s string) {
+		for _, r := range s {
+			clock = clock.Add(100 * time.Millisecond)
+			g.AddExec(NewInsertAction(b, b.Size(), string(r)))
+		}
+	}
+	backspace := func(n int) {
+		for i := 0; i < n; i++ {
+			clock = clock.Add(100 * time.Millisecond)
+			g.AddExec(NewEraseAction(b, Region{b.Size() - 1, b.Size()}))
+		}
+	}
+	check := func(exp string) {
+		t.Helper()
+		if d := b.Substr(Region{0, b.Size()}); d != exp {
+			t.Errorf("Expected %q, but got %q", exp, d)
+		}
+	}
+
+	typ("hello world")
+	backspace(3)
+	g.BreakGroup()
+	check("hello wo")
+	if l := us.Len(); l != 3 {
+		t.Fatalf("Expected 3 groups, but got %d", l)
+	}
+	us.Undo()
+	check("hello world")
+	us.Undo()
+	check("hello")
+	us.Undo()
+	check("")
+	for us.Redo() {
+	}
+
+	// Moving the cursor ends the group
+	typ("!")
+	clock = clock.Add(100 * time.Millisecond)
+	g.AddExec(NewInsertAction(b, 0, ">"))
+	g.BreakGroup()
+	check(">hello wo!")
+	us.Undo()
+	check("hello wo!")
+	us.Undo()
+	check("hello wo")
+
+	// As does waiting too long between edits
+	typ("ab")
+	clock = clock.Add(2 * time.Second)
+	typ("cd")
+	g.BreakGroup()
+	us.Undo()
+	check("hello woab")
+
+	// Other actions always get a group of their own
+	typ("x")
+	g.AddExec(NewReplaceAction(b, Region{0, 5}, "HELLO"))
+	typ("y")
+	g.BreakGroup()
+	check("HELLO woabxy")
+	us.Undo()
+	check("HELLO woabx")
+	us.Undo()
+	check("hello woabx")
+	us.Undo()
+	check("hello woab")
+}
@@ -9,12 +9,15 @@ import (
 )
 
 func TestActions(t *testing.T) {
+	testActions(t, NewBuffer())
+}
+
+func testActions(t *testing.T, buffer Buffer) {
 	type Test struct {
 		action   Action
 		expected string
 	}
 	const init = "hello world"
-	var buffer = NewBuffer()
 	buffer.Insert(0, init)
 	tests := []Test{
 		{NewInsertAction(buffer, 0, "hello"), "hellohello world"},
@@ -59,12 +62,15 @@ func TestActions(t *testing.T) {
 }
 
 func TestActionsUtf(t *testing.T) {
+	testActionsUtf(t, NewBuffer())
+}
+
+func testActionsUtf(t *testing.T, buffer Buffer) {
 	type Test struct {
 		action   Action
 		expected string
 	}
 	const init = "€þıœəßðĸʒ×ŋµåäö𝄞"
-	var buffer = NewBuffer()
 	buffer.Insert(0, init)
 
 	tests := []Test{
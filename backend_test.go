@@ -0,0 +1,113 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// The InnerBufferInterface implementations that are run
+// through the conformance tests
+var backends = []struct {
+	name string
+	new  func() InnerBufferInterface
+}{
+	{"rope", NewRopeBackend},
+	{"utf8rope", NewUTF8RopeBackend},
+	{"naive", NewNaiveBackend},
+	{"gap", NewGapBackend},
+	{"piece", func() InnerBufferInterface { return NewPieceTableBackend(nil) }},
+	{"crdt", func() InnerBufferInterface { return NewCRDTBuffer(0) }},
+}
+
+func TestBackendConformance(t *testing.T) {
+	for _, be := range backends {
+		newBuf := func() Buffer { return NewBufferWith(be.new()) }
+		t.Run(be.name, func(t *testing.T) {
+			testRowColLineWord(t, newBuf())
+			testLines(t, newBuf)
+			testActions(t, newBuf())
+			testActionsUtf(t, newBuf())
+			testBufferObserver(t, newBuf())
+		})
+	}
+}
+
+// Applies the same random edits to each backend and to a plain slice,
+// checking that they all agree after every edit.
+func TestBackendRandomEdits(t *testing.T) {
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			bi := be.new()
+			defer bi.Close()
+			var exp []rune
+			for i := 0; i < 500; i++ {
+				if p := rand.Intn(len(exp) + 1); len(exp) > 0 && rand.Intn(3) == 0 {
+					l := rand.Intn(len(exp) - Min(p, len(exp)-1))
+					bi.Erase(p, l)
+					exp = append(exp[:p:p], exp[Min(p+l, len(exp)):]...)
+				} else {
+					data := make([]rune, 1+rand.Intn(32))
+					fill(data)
+					bi.InsertR(p, data)
+					exp = append(exp[:p:p], append(data, exp[p:]...)...)
+				}
+				checkBackend(t, i, bi, exp)
+			}
+		})
+	}
+}
+
+func checkBackend(t *testing.T, i int, bi InnerBufferInterface, exp []rune) {
+	if s := bi.Size(); s != len(exp) {
+		t.Fatalf("%d: Expected size %d, but got %d", i, len(exp), s)
+	}
+	if d := string(bi.SubstrR(Region{0, len(exp)})); d != string(exp) {
+		t.Fatalf("%d: Expected %q, but got %q", i, string(exp), d)
+	}
+	if len(exp) == 0 {
+		return
+	}
+	ref := naiveBuffer{data: exp}
+	for j := 0; j < 8; j++ {
+		p := rand.Intn(len(exp))
+		if r := bi.Index(p); r != exp[p] {
+			t.Fatalf("%d: Expected %q at %d, but got %q", i, exp[p], p, r)
+		}
+		a := Region{rand.Intn(len(exp)), rand.Intn(len(exp))}
+		if d, e := string(bi.SubstrR(a)), string(ref.SubstrR(a)); d != e {
+			t.Fatalf("%d: Expected %q at %v, but got %q", i, e, a, d)
+		}
+		er, ec := ref.RowCol(p)
+		if r, c := bi.RowCol(p); r != er || c != ec {
+			t.Fatalf("%d: Expected %d, %d at %d, but got %d, %d", i, er, ec, p, r, c)
+		}
+		if p, e := bi.TextPoint(er, ec), ref.TextPoint(er, ec); p != e {
+			t.Fatalf("%d: Expected %d for %d, %d, but got %d", i, e, er, ec, p)
+		}
+	}
+}
+
+// Typing at a random place in the buffer, one rune at a time
+func BenchmarkBackendTyping(b *testing.B) {
+	data := make([]rune, 64*1024)
+	fill(data)
+	for _, be := range backends {
+		if be.name == "crdt" {
+			continue
+		}
+		b.Run(be.name, func(b *testing.B) {
+			bi := be.new()
+			bi.InsertR(0, data)
+			p := rand.Intn(len(data))
+			in := []rune{'a'}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bi.InsertR(p+i%1024, in)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ package text
 import (
 	"fmt"
 	"github.com/limetext/log4go"
+	"io"
 	"runtime"
 	"strings"
 	"sync"
@@ -76,6 +77,7 @@ type (
 		fmt.Stringer
 		InnerBufferInterface
 		IdInterface
+		SettingsInterface
 
 		// DEPRECATED! Use AddObserver instead! Add a BufferChangedCallback to the buffer
 		AddCallback(cb BufferChangedCallback) error
@@ -106,6 +108,14 @@ type (
 		LineR(r Region) Region
 		// Returns the lines intersecting the region
 		Lines(r Region) []Region
+		// Returns the number of lines in the buffer, which is one
+		// more than the number of newlines in it
+		LineCount() int
+		// Returns the text position of the start of the given
+		// line, or the size of the buffer if there is no such line
+		LineStart(line int) int
+		// Returns the line the text position is on
+		LineOf(offset int) int
 		// Like #Line, but includes the line endings
 		FullLine(offset int) Region
 		// Like #LineR, but includes the line endings
@@ -115,20 +125,159 @@ type (
 		// Returns the Region covering the start of the word in r.Begin()
 		// to the end of the word in r.End()
 		WordR(r Region) Region
+		// Sets what decides where words begin and end. By default, and
+		// if set to nil, the runes in the "word_separators" setting are
+		// the separators, as with WordSeparators.
+		SetWordClassifier(WordClassifier)
+
+		// Returns a read-only view of the buffer's current contents
+		// that is unaffected by any later modifications
+		Snapshot() Snapshot
+
+		// Returns the offset in UTF-8 bytes of the given text position
+		ByteOffset(point int) int
+		// Inverse of #ByteOffset. Offsets in the middle of a
+		// rune give the text position of that rune.
+		PointFromByte(offset int) int
+		// Returns the offset in UTF-16 code units of the given text position
+		UTF16Offset(point int) int
+		// Inverse of #UTF16Offset. Offsets in the middle of a
+		// surrogate pair give the text position of that rune.
+		PointFromUTF16(offset int) int
+		// Like #RowCol, but with the column counted in grapheme clusters
+		// rather than runes. Text positions inside of a grapheme cluster
+		// give the column of that cluster.
+		GraphemeRowCol(point int) (row, col int)
+		// Inverse of #GraphemeRowCol
+		GraphemeTextPoint(row, col int) int
+		// Like #RowCol, but with the column being the one the text
+		// position is displayed at. Grapheme clusters, wide characters
+		// and tabs, which advance to the next multiple of the
+		// "tab_size" setting, are taken into account.
+		VisualRowCol(point int) (row, col int)
+		// Inverse of #VisualRowCol. Columns in the middle of a wide
+		// character or a tab give the text position of it, and
+		// columns past the end of the line the end of it.
+		VisualTextPoint(row, col int) int
+
+		// Returns the first match of the pattern at or after start,
+		// or Region{-1, -1} if there is none. The pattern is a Go
+		// regular expression unless the Literal flag is given. The
+		// text is read straight from the buffer's storage, a piece at
+		// a time, rather than being copied into a string first.
+		Find(pattern string, start int, flags FindFlags) (Region, error)
+		// Returns all the non-overlapping matches of the pattern
+		FindAll(pattern string, flags FindFlags) ([]Region, error)
+		// Like #Find, but returns the last match ending at or before start
+		FindBackward(pattern string, start int, flags FindFlags) (Region, error)
+		// Replaces all the matches of the pattern within the regions of
+		// scope, or the whole buffer if scope is nil, with the template.
+		// Unless the Literal flag is given, $1 or ${name} in the template
+		// is replaced by the corresponding submatch as by regexp.Expand.
+		// Returns the action performed, which undoes all the replacements
+		// in one step.
+		ReplaceAll(pattern, template string, scope *RegionSet, flags FindFlags) (*CompositeAction, error)
+
+		// Replaces the contents of the buffer with the UTF-8 text read
+		// from r. The most common line ending in the text becomes the
+		// buffer's LineEnding, and all line endings are turned into "\n".
+		ReadFrom(r io.Reader) (n int64, err error)
+		// Writes the contents of the buffer to w as UTF-8 text,
+		// with every "\n" replaced by the buffer's LineEnding. Lines
+		// read with another line ending keep it until they are edited.
+		WriteTo(w io.Writer) (n int64, err error)
+		// Returns the line ending used when writing the buffer out
+		LineEnding() LineEnding
+		// Sets the line ending used when writing the buffer out,
+		// for all lines including those read with another one
+		SetLineEnding(LineEnding)
+
+		// Replaces the contents of the buffer with those of the given
+		// file and sets the buffer's file name to it. The encoding of the
+		// file and whether it starts with a byte order mark are detected
+		// and kept for when the buffer is saved, and its line endings
+		// are handled as by #ReadFrom.
+		//
+		// Bytes that can't be decoded are kept as the runes U+DC80 to
+		// U+DCFF, so that they are written back out unchanged, and can
+		// be found with #Undecodable. The trailing odd byte of a UTF-16
+		// file isn't part of the text, but is kept and written back out
+		// after it as long as the encoding is UTF-16.
+		LoadFile(path string) error
+		// Writes the contents of the buffer to the given file using the
+		// buffer's encoding, byte order mark and line ending, and sets the
+		// buffer's file name to it. Returns ErrCannotEncode if the buffer
+		// contains text that can't be represented in its encoding.
+		//
+		// Existing files keep their mode. By default the data is written
+		// to a temporary file that is synced to disk and then renamed over
+		// the original, which can be changed with the "atomic_save" and
+		// "fsync_on_save" settings. The "save_backup" setting can be set to
+		// "bak" or "numbered" to keep a copy of the file being overwritten.
+		//
+		// If the file is the one the buffer was loaded from or last saved
+		// to, and it has been modified since, a *ConflictError is returned.
+		SaveFile(path string) error
+		// Like #SaveFile, but overwrites the file even if it has been
+		// modified since the buffer was loaded
+		ForceSaveFile(path string) error
+		// Saves the buffer to its file name with #SaveFile
+		Save() error
+		// Returns whether the file the buffer was loaded from or last
+		// saved to has been modified or removed since
+		CheckExternalChange() (bool, error)
+		// Replaces the contents of the buffer with those of its file,
+		// as it is on disk right now. Rather than replacing everything,
+		// only the text that differs is erased and inserted, so that
+		// observers see the smallest changes needed. Returns the
+		// action performed, which can be undone.
+		Reload() (*CompositeAction, error)
+		// Returns the encoding used when saving the buffer
+		Encoding() Encoding
+		// Sets the encoding used when saving the buffer
+		SetEncoding(Encoding)
+		// Returns whether a byte order mark is written when saving the buffer
+		HasBOM() bool
+		// Sets whether a byte order mark is written when saving the buffer
+		SetBOM(bool)
+		// Returns the regions of the buffer containing bytes that couldn't
+		// be decoded when it was loaded, or any other runes that aren't
+		// valid Unicode text
+		Undecodable() []Region
 	}
 
 	// The BufferChangedCallback is called everytime a buffer is
 	// changed.
 	BufferChangedCallback func(buf Buffer, position, delta int)
 
+	// The read-only subset of InnerBufferInterface that the
+	// line and word lookups are implemented on top of.
+	reader interface {
+		Size() int
+		SubstrR(r Region) []rune
+		Index(int) rune
+		Line(offset int) Region
+	}
+
 	buffer struct {
 		HasId
+		HasSettings
 		SerializedBuffer
 		changecount int
 		name        string
 		filename    string
-		callbacks   []BufferChangedCallback
-		observers   map[BufferObserver]bool
+		lineEnding  LineEnding
+		encoding    Encoding
+		bom         bool
+		// The newlines that are written out as another
+		// line ending than lineEnding, in order
+		endings []endingAt
+		// The trailing odd byte of a UTF-16 file, if it had one
+		odd       []byte
+		disk      *diskState
+		words     WordClassifier
+		callbacks []BufferChangedCallback
+		observers map[BufferObserver]bool
 
 		inCallbacks int32
 
@@ -144,12 +293,21 @@ var (
 	ErrBufferInCallbacks    = fmt.Errorf("Buffer can not be modified when in a callback")
 )
 
-// Returns a new empty Buffer
+// Returns a new empty Buffer, storing its data in a rope
 func NewBuffer() Buffer {
+	return NewBufferWith(NewRopeBackend())
+}
+
+// Returns a new Buffer using the given InnerBufferInterface
+// implementation for storing its data. The buffer takes
+// ownership of bi, which should not be used directly anymore.
+func NewBufferWith(bi InnerBufferInterface) Buffer {
 	b := buffer{
 		observers: make(map[BufferObserver]bool),
 	}
-	b.SerializedBuffer.init(&rebalancingNode{})
+	// Set up eagerly, as Settings() would do it without a lock
+	b.settings = NewSettings()
+	b.SerializedBuffer.init(bi)
 	r := &b
 	runtime.SetFinalizer(r, func(b *buffer) { b.Close() })
 
@@ -264,6 +422,7 @@ func (buf *buffer) InsertR(point int, value []rune) error {
 	}
 	buf.lock.Lock()
 	buf.changecount++
+	buf.adjustEndings(point, len(value))
 	buf.lock.Unlock()
 	buf.notify(point, len(value))
 	for obs := range buf.observers {
@@ -297,6 +456,9 @@ func (buf *buffer) Erase(point, length int) error {
 	if err := buf.SerializedBuffer.Erase(point, length); err != nil {
 		return err
 	}
+	buf.lock.Lock()
+	buf.adjustEndings(re.A, -re.Size())
+	buf.lock.Unlock()
 
 	buf.notify(point+length, -length)
 	for obs := range buf.observers {
@@ -316,54 +478,23 @@ func (b *buffer) ChangeCount() int {
 }
 
 func (b *buffer) Line(offset int) Region {
-	if offset < 0 {
-		return Region{0, 0}
-	} else if s := b.Size(); offset >= s {
-		return Region{s, s}
-	}
-	soffset := offset
-sloop:
-	o := Clamp(0, soffset, soffset-32)
-	sub := b.SubstrR(Region{o, soffset})
-	s := soffset
-	for s > o && sub[s-o-1] != '\n' {
-		s--
-	}
-	if s == o && o > 0 && sub[0] != '\n' {
-		soffset = o
-		goto sloop
-	}
+	return b.SerializedBuffer.line(offset)
+}
 
-	l := b.Size()
-	eoffset := offset
-eloop:
-	o = Clamp(eoffset, l, eoffset+32)
-	sub = b.SubstrR(Region{eoffset, o})
-	e := eoffset
-	for e < o && sub[e-eoffset] != '\n' {
-		e++
-	}
-	if e == o && o < l && sub[o-eoffset-1] != '\n' {
-		eoffset = o
-		goto eloop
-	}
-	return Region{s, e}
+func (b *buffer) Lines(r Region) []Region {
+	return b.SerializedBuffer.lines(r)
 }
 
-func (b *buffer) Lines(r Region) (lines []Region) {
-	r = b.FullLineR(r)
-	buf := b.SubstrR(r)
-	last := r.Begin()
-	for i, ru := range buf {
-		if ru == '\n' {
-			lines = append(lines, Region{last, r.Begin() + i})
-			last = r.Begin() + i + 1
-		}
-	}
-	if last != r.End() {
-		lines = append(lines, Region{last, r.End()})
-	}
-	return
+func (b *buffer) LineCount() int {
+	return b.SerializedBuffer.newlines() + 1
+}
+
+func (b *buffer) LineStart(line int) int {
+	return b.SerializedBuffer.lineStart(line)
+}
+
+func (b *buffer) LineOf(offset int) int {
+	return b.SerializedBuffer.lineOf(offset)
 }
 
 func (b *buffer) LineR(r Region) Region {
@@ -373,14 +504,12 @@ func (b *buffer) LineR(r Region) Region {
 }
 
 func (b *buffer) FullLine(offset int) Region {
+	return fullLine(b, offset)
+}
+
+func fullLine(b reader, offset int) Region {
+	// Line ends at the newline, if there is one
 	r := b.Line(offset)
-	s := b.Size()
-	for r.B < s {
-		if i := b.Index(r.B); i == '\r' || i == '\n' {
-			break
-		}
-		r.B++
-	}
 	if r.B != b.Size() {
 		r.B++
 	}
@@ -394,10 +523,14 @@ func (b *buffer) FullLineR(r Region) Region {
 }
 
 func (b *buffer) Word(offset int) Region {
+	return word(b, offset, b.wordClassifier())
+}
+
+func word(b reader, offset int, wc WordClassifier) Region {
 	if offset < 0 {
 		offset = 0
 	}
-	lr := b.FullLine(offset)
+	lr := fullLine(b, offset)
 	col := offset - lr.Begin()
 
 	line := b.SubstrR(lr)
@@ -405,9 +538,7 @@ func (b *buffer) Word(offset int) Region {
 		return Region{offset, offset}
 	}
 
-	seps := "./\\()\"'-:,.;<>~!@#$%^&*|+=[]{}`~?"
 	spacing := " \n\t\r"
-	eseps := seps + spacing
 
 	if col >= len(line) {
 		col = len(line) - 1
@@ -417,7 +548,7 @@ func (b *buffer) Word(offset int) Region {
 	ls := false
 	lc := 0
 	for i, r := range line {
-		cur := strings.ContainsRune(eseps, r)
+		cur := strings.ContainsRune(spacing, r) || wc.IsSeparator(r)
 		cs := r == ' '
 		if !cs {
 			lc = i
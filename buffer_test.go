@@ -8,11 +8,15 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"math/rand"
+	"sync"
 	"testing"
 )
 
 func TestRowColLineWord(t *testing.T) {
-	var b = NewBuffer()
+	testRowColLineWord(t, NewBuffer())
+}
+
+func testRowColLineWord(t *testing.T, b Buffer) {
 	if d, err := ioutil.ReadFile("./testdata/unittest.json"); err != nil {
 		t.Fatal(err)
 	} else {
@@ -96,6 +100,10 @@ func TestRowColLineWord(t *testing.T) {
 }
 
 func TestLines(t *testing.T) {
+	testLines(t, NewBuffer)
+}
+
+func testLines(t *testing.T, newBuf func() Buffer) {
 	tests := []struct {
 		text   string
 		region Region
@@ -124,7 +132,7 @@ func TestLines(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		var b = NewBuffer()
+		var b = newBuf()
 		b.Insert(0, test.text)
 		rs := b.Lines(test.region)
 
@@ -140,6 +148,67 @@ func TestLines(t *testing.T) {
 	}
 }
 
+type countingBackend struct {
+	InnerBufferInterface
+	inserts, erases int
+}
+
+func (cb *countingBackend) InsertR(point int, data []rune) error {
+	cb.inserts++
+	return cb.InnerBufferInterface.InsertR(point, data)
+}
+
+func (cb *countingBackend) Erase(point, length int) error {
+	cb.erases++
+	return cb.InnerBufferInterface.Erase(point, length)
+}
+
+func TestNewBufferWith(t *testing.T) {
+	testRowColLineWord(t, NewBufferWith(NewNaiveBackend()))
+	testLines(t, func() Buffer { return NewBufferWith(NewNaiveBackend()) })
+
+	cb := &countingBackend{InnerBufferInterface: NewRopeBackend()}
+	b := NewBufferWith(cb)
+	defer b.Close()
+	do := &dummyObserver{}
+	b.AddObserver(do)
+
+	b.Insert(0, "hello world")
+	b.Erase(0, 6)
+	if cb.inserts != 1 || cb.erases != 1 {
+		t.Errorf("Expected 1 insert and 1 erase, but got %d and %d", cb.inserts, cb.erases)
+	}
+	if d := b.Substr(Region{0, b.Size()}); d != "world" {
+		t.Errorf("Expected %q, but got %q", "world", d)
+	}
+	if exp := (Region{0, 6}); do.erased.r != exp {
+		t.Errorf("Expected the observer to see %v erased, but got %v", exp, do.erased.r)
+	}
+}
+
+// Both look up settings of a new buffer, which must
+// not be set up by whichever goroutine gets there first
+func TestNewBufferSettingsConcurrent(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "hello\tworld")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := b.Word(1); w != (Region{0, 5}) {
+				t.Errorf("Expected %v, but got %v", Region{0, 5}, w)
+			}
+			if _, c := b.VisualRowCol(6); c != 8 {
+				t.Errorf("Expected column %d, but got %d", 8, c)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func fill(data []rune) {
 	s := int('a')
 	e := int('z')
@@ -52,7 +52,10 @@ func (do *dummyObserver) Erased(b Buffer, r Region, d []rune) {
 }
 
 func TestBufferObserver(t *testing.T) {
-	var b = NewBuffer()
+	testBufferObserver(t, NewBuffer())
+}
+
+func testBufferObserver(t *testing.T, b Buffer) {
 	defer b.Close()
 
 	do1 := &dummyObserver{}
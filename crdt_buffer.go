@@ -0,0 +1,304 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"sync"
+)
+
+type (
+	// CRDTId uniquely identifies a rune ever inserted into a CRDTBuffer.
+	// Seq is a Lamport timestamp, and Site identifies the CRDTBuffer
+	// the rune was originally inserted into.
+	CRDTId struct {
+		Seq  Id
+		Site Id
+	}
+
+	// CRDTOp is an operation exported from a CRDTBuffer to be imported
+	// by its replicas at other sites.
+	CRDTOp struct {
+		// The id of the inserted or deleted rune
+		Id CRDTId
+		// The rune the inserted rune was inserted after, or the zero
+		// CRDTId if it was inserted at the very beginning
+		Origin CRDTId
+		Rune   rune
+		Delete bool
+	}
+
+	// CRDTBuffer is an InnerBufferInterface implementation based on
+	// the Replicated Growable Array sequence CRDT
+	// (http://en.wikipedia.org/wiki/Conflict-free_replicated_data_type).
+	//
+	// Every rune gets a unique id when inserted, and erased runes are
+	// kept as tombstones. Operations exported from one replica can be
+	// imported by the others in any order, and once all replicas have
+	// seen the same operations their contents are identical, without
+	// any need for a central server.
+	CRDTBuffer struct {
+		site  Id
+		clock Id
+		elems []crdtElem
+		size  int
+		// Local operations not yet exported
+		ops []CRDTOp
+		// Imported operations waiting for the rune they refer to
+		pending []CRDTOp
+		lock    sync.Mutex
+	}
+
+	crdtElem struct {
+		id      CRDTId
+		r       rune
+		deleted bool
+	}
+)
+
+// NewCRDTBuffer returns a new empty CRDTBuffer for the given site, which
+// needs to be different for every replica. If site is 0, an id unique
+// within this process is used.
+func NewCRDTBuffer(site Id) *CRDTBuffer {
+	if site == 0 {
+		site = nextId()
+	}
+	return &CRDTBuffer{site: site}
+}
+
+func (id CRDTId) String() string {
+	return fmt.Sprintf("%d@%d", id.Seq, id.Site)
+}
+
+// Returns whether id was inserted after other, in the total order
+// used to resolve concurrent insertions at the same place
+func (id CRDTId) after(other CRDTId) bool {
+	return id.Seq > other.Seq || (id.Seq == other.Seq && id.Site > other.Site)
+}
+
+// Site returns the site id of the buffer
+func (b *CRDTBuffer) Site() Id {
+	return b.site
+}
+
+func (b *CRDTBuffer) Close() {
+}
+
+func (b *CRDTBuffer) Size() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.size
+}
+
+// Returns the index into elems of the visible rune at the given
+// position, or len(elems) if there is no such rune
+func (b *CRDTBuffer) elem(pos int) int {
+	for i := range b.elems {
+		if b.elems[i].deleted {
+			continue
+		}
+		if pos == 0 {
+			return i
+		}
+		pos--
+	}
+	return len(b.elems)
+}
+
+// Returns the index into elems of the rune with the given id, or -1
+func (b *CRDTBuffer) find(id CRDTId) int {
+	for i := range b.elems {
+		if b.elems[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the visible runes, optionally limited to the given region
+func (b *CRDTBuffer) runes(a, e int) []rune {
+	data := make([]rune, 0, e-a)
+	pos := 0
+	for i := range b.elems {
+		if pos >= e {
+			break
+		}
+		if b.elems[i].deleted {
+			continue
+		}
+		if pos >= a {
+			data = append(data, b.elems[i].r)
+		}
+		pos++
+	}
+	return data
+}
+
+func (b *CRDTBuffer) SubstrR(r Region) []rune {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	a, e := Clamp(0, b.size, r.Begin()), Clamp(0, b.size, r.End())
+	return b.runes(a, e)
+}
+
+func (b *CRDTBuffer) Index(pos int) rune {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pos < 0 || pos >= b.size {
+		panic(fmt.Sprintf("Index out of bounds: %d >= %d", pos, b.size))
+	}
+	return b.elems[b.elem(pos)].r
+}
+
+func (b *CRDTBuffer) InsertR(point int, data []rune) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	point = Clamp(0, b.size, point)
+
+	var origin CRDTId
+	i := 0
+	if point > 0 {
+		i = b.elem(point-1) + 1
+		origin = b.elems[i-1].id
+	}
+	ins := make([]crdtElem, len(data))
+	for j, r := range data {
+		b.clock++
+		id := CRDTId{b.clock, b.site}
+		ins[j] = crdtElem{id: id, r: r}
+		b.ops = append(b.ops, CRDTOp{Id: id, Origin: origin, Rune: r})
+		origin = id
+	}
+	// As the new ids are greater than any id seen so far, they go
+	// right after their origin.
+	b.elems = append(b.elems[:i], append(ins, b.elems[i:]...)...)
+	b.size += len(data)
+	return nil
+}
+
+func (b *CRDTBuffer) Erase(point, length int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	point = Clamp(0, b.size, point)
+	length = Clamp(0, b.size-point, length)
+	for i := b.elem(point); length > 0; i++ {
+		if e := &b.elems[i]; !e.deleted {
+			e.deleted = true
+			b.ops = append(b.ops, CRDTOp{Id: e.id, Delete: true})
+			b.size--
+			length--
+		}
+	}
+	return nil
+}
+
+func (b *CRDTBuffer) RowCol(point int) (row, col int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	point = Clamp(0, b.size, point)
+	for _, r := range b.runes(0, point) {
+		if r == '\n' {
+			row++
+			col = 0
+		} else {
+			col++
+		}
+	}
+	return
+}
+
+func (b *CRDTBuffer) TextPoint(row, col int) (i int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if row == 0 && col == 0 {
+		return 0
+	}
+	data := b.runes(0, b.size)
+	for l := len(data); row > 0 && i < l; i++ {
+		if data[i] == '\n' {
+			row--
+		}
+	}
+	if i < len(data) {
+		return i + col
+	}
+	return i
+}
+
+// Export returns the operations made locally since the last call
+// to Export, to be imported by the other replicas.
+func (b *CRDTBuffer) Export() []CRDTOp {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	ret := b.ops
+	b.ops = nil
+	return ret
+}
+
+// Import applies operations exported by other replicas. Operations
+// may be imported in any order and more than once; those referring
+// to runes that haven't been seen yet are held back until they have.
+//
+// Note that when the CRDTBuffer is used as the backend of a Buffer,
+// importing operations bypasses the Buffer's observers.
+func (b *CRDTBuffer) Import(ops []CRDTOp) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	ops = append(b.pending, ops...)
+	b.pending = nil
+	for progress := true; progress; {
+		progress = false
+		var waiting []CRDTOp
+		for _, op := range ops {
+			if b.integrate(op) {
+				progress = true
+			} else {
+				waiting = append(waiting, op)
+			}
+		}
+		ops = waiting
+	}
+	b.pending = ops
+}
+
+// Integrates a single remote operation. Returns false if the operation
+// refers to a rune that isn't known yet.
+func (b *CRDTBuffer) integrate(op CRDTOp) bool {
+	if op.Id.Seq > b.clock {
+		b.clock = op.Id.Seq
+	}
+	if op.Delete {
+		i := b.find(op.Id)
+		if i < 0 {
+			return false
+		}
+		if !b.elems[i].deleted {
+			b.elems[i].deleted = true
+			b.size--
+		}
+		return true
+	}
+	if b.find(op.Id) >= 0 {
+		// Already integrated
+		return true
+	}
+	i := 0
+	if op.Origin != (CRDTId{}) {
+		if i = b.find(op.Origin); i < 0 {
+			return false
+		}
+		i++
+	}
+	// Skip over the runes inserted concurrently after the same origin
+	// that win over this one, along with everything inserted after them.
+	for i < len(b.elems) && b.elems[i].id.after(op.Id) {
+		i++
+	}
+	b.elems = append(b.elems, crdtElem{})
+	copy(b.elems[i+1:], b.elems[i:])
+	b.elems[i] = crdtElem{id: op.Id, r: op.Rune}
+	b.size++
+	return true
+}
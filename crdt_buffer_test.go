@@ -0,0 +1,79 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCRDTBufferConcurrent(t *testing.T) {
+	const (
+		init  = "hello world"
+		sites = 3
+	)
+	origin := NewCRDTBuffer(0)
+	origin.InsertR(0, []rune(init))
+	initOps := origin.Export()
+
+	var bs [sites]*CRDTBuffer
+	for i := range bs {
+		bs[i] = NewCRDTBuffer(0)
+		bs[i].Import(initOps)
+	}
+	// Exported but not yet imported operations, per receiving site
+	var inbox [sites][]CRDTOp
+
+	for step := 0; step < 300; step++ {
+		i := rand.Intn(sites)
+		b := bs[i]
+		switch rand.Intn(3) {
+		case 0:
+			data := make([]rune, 1+rand.Intn(3))
+			fill(data)
+			b.InsertR(rand.Intn(b.Size()+1), data)
+		case 1:
+			if s := b.Size(); s > 0 {
+				p := rand.Intn(s)
+				b.Erase(p, 1+rand.Intn(Min(s-p, 4)))
+			}
+		case 2:
+			ops := b.Export()
+			for j := range inbox {
+				if j != i {
+					inbox[j] = append(inbox[j], ops...)
+				}
+			}
+		}
+		if j := rand.Intn(sites); len(inbox[j]) > 0 {
+			// Deliver a random part of the inbox, in random order
+			n := 1 + rand.Intn(len(inbox[j]))
+			rand.Shuffle(len(inbox[j]), func(a, b int) { inbox[j][a], inbox[j][b] = inbox[j][b], inbox[j][a] })
+			bs[j].Import(inbox[j][:n])
+			inbox[j] = inbox[j][n:]
+		}
+	}
+	for i, b := range bs {
+		ops := b.Export()
+		for j := range inbox {
+			if j != i {
+				inbox[j] = append(inbox[j], ops...)
+			}
+		}
+	}
+	for j := range bs {
+		bs[j].Import(inbox[j])
+	}
+
+	exp := string(bs[0].SubstrR(Region{0, bs[0].Size()}))
+	for i, b := range bs {
+		if len(b.pending) != 0 {
+			t.Errorf("Site %d has %d pending operations", i, len(b.pending))
+		}
+		if d := string(b.SubstrR(Region{0, b.Size()})); d != exp {
+			t.Errorf("Site %d didn't converge: %q != %q", i, d, exp)
+		}
+	}
+}
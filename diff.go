@@ -0,0 +1,267 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+type (
+	// Hunk is a part that differs between two texts. The region A in
+	// the first text is replaced by the region B in the second text.
+	// Either of them may be empty, for pure insertions and deletions.
+	Hunk struct {
+		A, B Region
+	}
+)
+
+// Returns the hunks turning the first n elements of one sequence into
+// the first m of another, using the linear space variant of Myers'
+// O(ND) algorithm. eq reports whether the i:th element of the first
+// sequence equals the j:th of the second. The regions of the hunks
+// are element indices.
+func myers(n, m int, eq func(i, j int) bool) (ret []Hunk) {
+	max := (n+m+1)/2 + 1
+	d := differ{
+		eq:  eq,
+		del: make([]bool, n),
+		ins: make([]bool, m),
+		// Shared by every step of the recursion, as
+		// each is done with them before recursing
+		vf: make([]int, 2*max+1),
+		vb: make([]int, 2*max+1),
+	}
+	d.compare(0, n, 0, m)
+
+	// Whatever isn't deleted from the first or inserted into the second
+	// is their common subsequence, so the hunks are what lies in between
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && !d.del[i] && !d.ins[j] {
+			i++
+			j++
+			continue
+		}
+		h := Hunk{Region{i, i}, Region{j, j}}
+		for i < n && d.del[i] {
+			i++
+		}
+		for j < m && d.ins[j] {
+			j++
+		}
+		h.A.B, h.B.B = i, j
+		ret = append(ret, h)
+	}
+	return
+}
+
+// The state of a diff in progress
+type differ struct {
+	eq func(i, j int) bool
+	// The elements deleted from the first sequence
+	// and inserted into the second
+	del, ins []bool
+	// The furthest reaching x of each diagonal k, at index
+	// k+len/2, going forwards from the start and backwards
+	// from the end
+	vf, vb []int
+}
+
+// Marks the differences between the elements aLo to aHi of the first
+// sequence and bLo to bHi of the second, by finding the middle of the
+// shortest path of edits between them and then recursing on what
+// comes before and after it
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Common prefixes and suffixes are by far the most common case
+	for aLo < aHi && bLo < bHi && d.eq(aLo, bLo) {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.eq(aHi-1, bHi-1) {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.ins[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.del[i] = true
+		}
+	default:
+		x0, y0, x1, y1 := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, aLo+x0, bLo, bLo+y0)
+		d.compare(aLo+x1, aHi, bLo+y1, bHi)
+	}
+}
+
+// Returns the start and end, relative to aLo and bLo, of the snake in
+// the middle of a shortest path of edits between the elements aLo to
+// aHi of the first sequence and bLo to bHi of the second
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x0, y0, x1, y1 int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta&1 != 0
+	off := len(d.vf) / 2
+	d.vf[off+1] = 0
+	d.vb[off+1] = 0
+	for D := 0; D <= (n+m+1)/2; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && d.vf[off+k-1] < d.vf[off+k+1]) {
+				x = d.vf[off+k+1]
+			} else {
+				x = d.vf[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.eq(aLo+x, bLo+y) {
+				x++
+				y++
+			}
+			d.vf[off+k] = x
+			// Meeting a backward path on the same diagonal
+			if kb := delta - k; odd && kb >= -(D-1) && kb <= D-1 && x+d.vb[off+kb] >= n {
+				return sx, sy, x, y
+			}
+		}
+		// Going backwards, x and y count from the ends
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && d.vb[off+k-1] < d.vb[off+k+1]) {
+				x = d.vb[off+k+1]
+			} else {
+				x = d.vb[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.eq(aHi-1-x, bHi-1-y) {
+				x++
+				y++
+			}
+			d.vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -D && kf <= D && x+d.vf[off+kf] >= n {
+				return n - x, m - y, n - sx, m - sy
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// DiffRunes returns the hunks that differ between a and b, with
+// the regions of the hunks being rune offsets into a and b.
+func DiffRunes(a, b []rune) []Hunk {
+	return myers(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+}
+
+// Returns the regions of the lines in the buffer, including
+// their line endings
+func fullLines(b Buffer) []Region {
+	lines := b.Lines(Region{0, b.Size()})
+	for i := range lines {
+		if i+1 < len(lines) {
+			lines[i].B = lines[i+1].A
+		} else {
+			lines[i].B = b.Size()
+		}
+	}
+	return lines
+}
+
+// Diff compares a and b line by line, returning the hunks that
+// differ between them. The regions of the hunks are rune offsets
+// into a and b, always covering whole lines including their line
+// endings.
+func Diff(a, b Buffer) (ret []Hunk) {
+	la, lb := fullLines(a), fullLines(b)
+	// Numbering the lines so that comparing them is cheap
+	ids := make(map[string]int)
+	id := func(b Buffer, lines []Region) []int {
+		ret := make([]int, len(lines))
+		for i, l := range lines {
+			s := b.Substr(l)
+			n, ok := ids[s]
+			if !ok {
+				n = len(ids)
+				ids[s] = n
+			}
+			ret[i] = n
+		}
+		return ret
+	}
+	ia, ib := id(a, la), id(b, lb)
+
+	offset := func(lines []Region, i int, size int) int {
+		if i < len(lines) {
+			return lines[i].A
+		}
+		return size
+	}
+	for _, h := range myers(len(ia), len(ib), func(i, j int) bool {
+		return ia[i] == ib[j]
+	}) {
+		ret = append(ret, Hunk{
+			Region{offset(la, h.A.A, a.Size()), offset(la, h.A.B, a.Size())},
+			Region{offset(lb, h.B.A, b.Size()), offset(lb, h.B.B, b.Size())},
+		})
+	}
+	return
+}
+
+// DiffString is like Diff, comparing the buffer a with the text s.
+func DiffString(a Buffer, s string) []Hunk {
+	return diffText(a, []rune(s))
+}
+
+func diffText(a Buffer, text []rune) []Hunk {
+	b := NewBuffer()
+	defer b.Close()
+	b.InsertR(0, text)
+	return Diff(a, b)
+}
+
+// The largest hunk, counting the runes of both sides, that refine
+// splits up. Diffing takes time proportional to the size times the
+// number of differences, so larger hunks are left as they are.
+const maxRefineSize = 4096
+
+// Splits each of the hunks, which are regions of a and b, into the
+// smaller hunks that actually differ between a and b
+func refine(a, b []rune, hunks []Hunk) (ret []Hunk) {
+	for _, h := range hunks {
+		if h.A.Size()+h.B.Size() > maxRefineSize {
+			ret = append(ret, h)
+			continue
+		}
+		for _, r := range DiffRunes(a[h.A.Begin():h.A.End()], b[h.B.Begin():h.B.End()]) {
+			r.A.A += h.A.Begin()
+			r.A.B += h.A.Begin()
+			r.B.A += h.B.Begin()
+			r.B.B += h.B.Begin()
+			ret = append(ret, r)
+		}
+	}
+	return
+}
+
+// DiffAction returns an action that transforms the buffer into the
+// text b, given the hunks from diffing the two. The action has not
+// been applied when returned.
+func DiffAction(buf Buffer, b []rune, hunks []Hunk) *CompositeAction {
+	ca := &CompositeAction{}
+	// Going backwards so that the regions of the
+	// remaining hunks are unaffected
+	for i := len(hunks) - 1; i >= 0; i-- {
+		h := hunks[i]
+		if h.A.Size() > 0 {
+			ca.Add(NewEraseAction(buf, h.A))
+		}
+		if h.B.Size() > 0 {
+			value := append([]rune(nil), b[h.B.Begin():h.B.End()]...)
+			ca.Add(&insertAction{buf, h.A.Begin(), value})
+		}
+	}
+	return ca
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Checks that the hunks are in order, and that what lies
+// between them is the same in a and b
+func checkHunks(t *testing.T, a, b []rune, hunks []Hunk) {
+	i, j := 0, 0
+	for _, h := range hunks {
+		if h.A.A < i || h.B.A < j || h.A.A-i != h.B.A-j {
+			t.Errorf("Hunk %v out of place in %v", h, hunks)
+			return
+		}
+		if string(a[i:h.A.A]) != string(b[j:h.B.A]) {
+			t.Errorf("Expected %q and %q to be equal", string(a[i:h.A.A]), string(b[j:h.B.A]))
+		}
+		i, j = h.A.B, h.B.B
+	}
+	if string(a[i:]) != string(b[j:]) {
+		t.Errorf("Expected %q and %q to be equal", string(a[i:]), string(b[j:]))
+	}
+}
+
+func TestDiffRunes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		exp  []Hunk
+	}{
+		{"", "", nil},
+		{"abc", "abc", nil},
+		{"abc", "abxc", []Hunk{{Region{2, 2}, Region{2, 3}}}},
+		{"abxc", "abc", []Hunk{{Region{2, 3}, Region{2, 2}}}},
+		{"abc", "", []Hunk{{Region{0, 3}, Region{0, 0}}}},
+		{"", "abc", []Hunk{{Region{0, 0}, Region{0, 3}}}},
+		{"abcdef", "aXcdYf", []Hunk{{Region{1, 2}, Region{1, 2}}, {Region{4, 5}, Region{4, 5}}}},
+		{"abcabba", "cbabac", nil},
+	}
+	for i, test := range tests {
+		a, b := []rune(test.a), []rune(test.b)
+		hunks := DiffRunes(a, b)
+		if test.exp != nil && !reflect.DeepEqual(hunks, test.exp) {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.exp, hunks)
+		}
+		checkHunks(t, a, b, hunks)
+	}
+
+	// The classic example from Myers' paper has an edit distance of 5
+	hunks := DiffRunes([]rune("abcabba"), []rune("cbabac"))
+	n := 0
+	for _, h := range hunks {
+		n += h.A.Size() + h.B.Size()
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 edits, but got %d: %v", n, hunks)
+	}
+}
+
+func TestDiffRunesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	text := func() []rune {
+		ret := make([]rune, r.Intn(40))
+		for i := range ret {
+			ret[i] = 'a' + rune(r.Intn(3))
+		}
+		return ret
+	}
+	for i := 0; i < 500; i++ {
+		a, b := text(), text()
+		hunks := DiffRunes(a, b)
+		checkHunks(t, a, b, hunks)
+
+		// The edits are as few as the longest common subsequence allows
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = Max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		n := 0
+		for _, h := range hunks {
+			n += h.A.Size() + h.B.Size()
+		}
+		if exp := len(a) + len(b) - 2*lcs[0][0]; n != exp {
+			t.Errorf("Test %d: Expected %d edits, but got %d", i, exp, n)
+		}
+	}
+}
+
+func TestRefine(t *testing.T) {
+	a := []rune("x\nabc\ny\n")
+	b := []rune("x\naXc\ny\n")
+	hunks := []Hunk{{Region{2, 6}, Region{2, 6}}}
+	if h := refine(a, b, hunks); !reflect.DeepEqual(h, []Hunk{{Region{3, 4}, Region{3, 4}}}) {
+		t.Errorf("Unexpected hunks %v", h)
+	}
+
+	// Hunks too large to diff quickly are kept whole
+	a = make([]rune, maxRefineSize)
+	b = make([]rune, 1)
+	for i := range a {
+		a[i] = 'a'
+	}
+	hunks = []Hunk{{Region{0, len(a)}, Region{0, len(b)}}}
+	if h := refine(a, b, hunks); !reflect.DeepEqual(h, hunks) {
+		t.Errorf("Expected %v, but got %v", hunks, h)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := NewBuffer()
+	defer a.Close()
+	b := NewBuffer()
+	defer b.Close()
+	a.Insert(0, "one\ntwo\nthree\nfour\nfive")
+	b.Insert(0, "one\n2\nthree\nfour\nfour and a half\nfive\n")
+
+	exp := []Hunk{
+		{Region{4, 8}, Region{4, 6}},
+		{Region{19, 23}, Region{17, 38}},
+	}
+	if hunks := Diff(a, b); !reflect.DeepEqual(hunks, exp) {
+		t.Errorf("Expected %v, but got %v", exp, hunks)
+	}
+	if hunks := DiffString(a, b.Substr(Region{0, b.Size()})); !reflect.DeepEqual(hunks, exp) {
+		t.Errorf("Expected %v, but got %v", exp, hunks)
+	}
+	if hunks := Diff(a, a); len(hunks) != 0 {
+		t.Errorf("Expected no hunks, but got %v", hunks)
+	}
+	if hunks := DiffString(a, ""); !reflect.DeepEqual(hunks, []Hunk{{Region{0, 23}, Region{0, 0}}}) {
+		t.Errorf("Unexpected hunks %v", hunks)
+	}
+}
+
+func TestDiffAction(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	line := func() string {
+		return []string{"a\n", "b\n", "c\n", "d"}[r.Intn(4)]
+	}
+	for i := 0; i < 100; i++ {
+		var sa, sb string
+		for j := r.Intn(10); j > 0; j-- {
+			sa += line()
+		}
+		for j := r.Intn(10); j > 0; j-- {
+			sb += line()
+		}
+		a := NewBuffer()
+		a.Insert(0, sa)
+		text := []rune(sb)
+		hunks := DiffString(a, sb)
+		checkHunks(t, []rune(sa), text, hunks)
+
+		ca := DiffAction(a, text, refine([]rune(sa), text, hunks))
+		ca.Apply()
+		if s := a.Substr(Region{0, a.Size()}); s != sb {
+			t.Errorf("Test %d: Expected %q, but got %q", i, sb, s)
+		}
+		ca.Undo()
+		if s := a.Substr(Region{0, a.Size()}); s != sa {
+			t.Errorf("Test %d: Expected %q after undoing, but got %q", i, sa, s)
+		}
+		a.Close()
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a file.
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	UTF16LE
+	UTF16BE
+	// ISO-8859-1
+	Latin1
+)
+
+var (
+	ErrCannotEncode = fmt.Errorf("Text can not be represented in the encoding")
+
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// Bytes that can't be decoded are turned into the runes U+DC80 to
+// U+DCFF, which are unpaired surrogate halves and thus never part of
+// valid text. When the text is encoded again, they are turned back
+// into the original bytes. In UTF-16 the only byte that can't be
+// decoded is a trailing odd one, which is kept outside of the text
+// by decodeFile, as any rune could be a real unpaired surrogate.
+const escapeBase = 0xdc00
+
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case Latin1:
+		return "ISO-8859-1"
+	}
+	return "Encoding(?)"
+}
+
+// Returns the byte order mark of the encoding, if it has one
+func (e Encoding) bom() []byte {
+	switch e {
+	case UTF8:
+		return bomUTF8
+	case UTF16LE:
+		return bomUTF16LE
+	case UTF16BE:
+		return bomUTF16BE
+	}
+	return nil
+}
+
+// Returns whether the rune is a surrogate half, which is what both
+// undecodable bytes and unpaired UTF-16 surrogates are decoded as
+func isSurrogate(r rune) bool {
+	return r >= 0xd800 && r <= 0xdfff
+}
+
+// DetectEncoding guesses the encoding of the data, returning it along
+// with whether the data starts with the encoding's byte order mark.
+//
+// Without a byte order mark, data that is mostly valid UTF-8 is
+// considered to be UTF-8, data where most of every other byte is zero
+// UTF-16 and anything else Latin-1.
+func DetectEncoding(data []byte) (Encoding, bool) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8, true
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE, true
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE, true
+	}
+	if utf8.Valid(data) {
+		return UTF8, false
+	}
+	var zeros [2]int
+	for i, c := range data {
+		if c == 0 {
+			zeros[i%2]++
+		}
+	}
+	if n := len(data) / 2; n > 0 {
+		if zeros[1] > n/2 && zeros[0] <= n/8 {
+			return UTF16LE, false
+		} else if zeros[0] > n/2 && zeros[1] <= n/8 {
+			return UTF16BE, false
+		}
+	}
+	// A single valid multi-byte sequence makes it
+	// unlikely that the data isn't meant to be UTF-8
+	for i := 0; i < len(data); {
+		r, l := utf8.DecodeRune(data[i:])
+		if r != utf8.RuneError && l > 1 {
+			return UTF8, false
+		}
+		i += l
+	}
+	return Latin1, false
+}
+
+// Decodes the data, which must not start with a byte order mark.
+// Bytes that can't be decoded are escaped as described for escapeBase,
+// and unpaired UTF-16 surrogates are kept as is. A trailing odd byte
+// of UTF-16 data is left out.
+func decode(data []byte, e Encoding) []rune {
+	var ret []rune
+	switch e {
+	case UTF16LE, UTF16BE:
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if e == UTF16LE {
+				units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+			} else {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			}
+		}
+		// utf16.Decode would replace unpaired surrogates with U+FFFD
+		ret = make([]rune, 0, len(units))
+		for i := 0; i < len(units); i++ {
+			r := rune(units[i])
+			if utf16.IsSurrogate(r) && i+1 < len(units) {
+				if r2 := utf16.DecodeRune(r, rune(units[i+1])); r2 != utf8.RuneError {
+					r = r2
+					i++
+				}
+			}
+			ret = append(ret, r)
+		}
+	case Latin1:
+		ret = make([]rune, len(data))
+		for i, c := range data {
+			ret[i] = rune(c)
+		}
+	default:
+		ret = make([]rune, 0, len(data))
+		for i := 0; i < len(data); {
+			r, l := decodeEscaped(data[i:])
+			ret = append(ret, r)
+			i += l
+		}
+	}
+	return ret
+}
+
+// Decodes the first UTF-8 encoded rune of p like utf8.DecodeRune,
+// but escaping an undecodable byte as described for escapeBase
+func decodeEscaped(p []byte) (rune, int) {
+	r, l := utf8.DecodeRune(p)
+	if r == utf8.RuneError && l == 1 {
+		r = escapeBase + rune(p[0])
+	}
+	return r, l
+}
+
+// Encodes the runes, without adding a byte order mark. Returns
+// ErrCannotEncode if any of them can't be represented in the encoding.
+func encode(data []rune, e Encoding) ([]byte, error) {
+	ret := make([]byte, 0, len(data))
+	switch e {
+	case UTF16LE, UTF16BE:
+		for _, r := range data {
+			if !utf8.ValidRune(r) && !isSurrogate(r) {
+				return nil, ErrCannotEncode
+			}
+			var units [2]rune
+			n := 1
+			if units[0] = r; r >= 0x10000 {
+				units[0], units[1] = utf16.EncodeRune(r)
+				n = 2
+			}
+			for _, u := range units[:n] {
+				if e == UTF16LE {
+					ret = append(ret, byte(u), byte(u>>8))
+				} else {
+					ret = append(ret, byte(u>>8), byte(u))
+				}
+			}
+		}
+	case Latin1:
+		for _, r := range data {
+			switch {
+			case r >= escapeBase+0x80 && r <= escapeBase+0xff:
+				ret = append(ret, byte(r-escapeBase))
+			case r > 0xff || r < 0:
+				return nil, ErrCannotEncode
+			default:
+				ret = append(ret, byte(r))
+			}
+		}
+	default:
+		var tmp [utf8.UTFMax]byte
+		for _, r := range data {
+			switch {
+			case r >= escapeBase+0x80 && r <= escapeBase+0xff:
+				ret = append(ret, byte(r-escapeBase))
+			case isSurrogate(r) || !utf8.ValidRune(r):
+				return nil, ErrCannotEncode
+			default:
+				ret = append(ret, tmp[:utf8.EncodeRune(tmp[:], r)]...)
+			}
+		}
+	}
+	return ret, nil
+}
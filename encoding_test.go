@@ -0,0 +1,101 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		in  []byte
+		enc Encoding
+		bom bool
+	}{
+		{nil, UTF8, false},
+		{[]byte("abc"), UTF8, false},
+		{[]byte("\xef\xbb\xbfabc"), UTF8, true},
+		{[]byte("\xff\xfea\x00"), UTF16LE, true},
+		{[]byte("\xfe\xff\x00a"), UTF16BE, true},
+		{[]byte("h\x00e\x00l\x00l\x00\xe5\x00"), UTF16LE, false},
+		{[]byte("\x00h\x00e\x00l\x00l\x00\xe5"), UTF16BE, false},
+		{[]byte("r\xe4ksm\xf6rg\xe5s"), Latin1, false},
+		{[]byte("r\xc3\xa4ksm\xf6rg\xc3\xa5s"), UTF8, false},
+	}
+	for i, test := range tests {
+		if enc, bom := DetectEncoding(test.in); enc != test.enc || bom != test.bom {
+			t.Errorf("Test %d: Expected %s, %v, but got %s, %v", i, test.enc, test.bom, enc, bom)
+		}
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	tests := []struct {
+		in  []byte
+		enc Encoding
+		exp []rune
+	}{
+		{[]byte("a\xc3\xa5\xf0\x9d\x84\x9e"), UTF8, []rune("aå𝄞")},
+		{[]byte("a\xff\xc3b\xc3\xa5"), UTF8, []rune{'a', 0xdcff, 0xdcc3, 'b', 'å'}},
+		{[]byte("a\x00\xe5\x00\x34\xd8\x1e\xdd"), UTF16LE, []rune("aå𝄞")},
+		{[]byte("\x00a\x00\xe5\xd8\x34\xdd\x1e"), UTF16BE, []rune("aå𝄞")},
+		{[]byte("r\xe4k"), Latin1, []rune("räk")},
+	}
+	for i, test := range tests {
+		d := decode(test.in, test.enc)
+		if !reflect.DeepEqual(d, test.exp) {
+			t.Errorf("Test %d: Expected %x, but got %x", i, test.exp, d)
+		}
+		if e, err := encode(d, test.enc); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if !bytes.Equal(e, test.in) {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.in, e)
+		}
+	}
+
+	// A trailing odd byte can't be decoded, so it is kept apart from
+	// the text, which might end with a real unpaired surrogate
+	files := []struct {
+		in  []byte
+		exp []rune
+		odd []byte
+	}{
+		{[]byte("\xff\xfea\x00b"), []rune{'a'}, []byte("b")},
+		{[]byte("\xff\xfea\x00\xe5"), []rune{'a'}, []byte("\xe5")},
+		{[]byte("\xff\xfe\x00"), []rune{}, []byte("\x00")},
+		{[]byte("\xff\xfea\x00A\xdc"), []rune{'a', 0xdc41}, nil},
+		{[]byte("\xff\xfea\x00A\xdcb"), []rune{'a', 0xdc41}, []byte("b")},
+	}
+	for i, test := range files {
+		d, enc, bom, odd := decodeFile(test.in)
+		if enc != UTF16LE || !bom || !reflect.DeepEqual(d, test.exp) || !bytes.Equal(odd, test.odd) {
+			t.Errorf("Test %d: Unexpected decoding %x, %s, %v, %q", i, d, enc, bom, odd)
+		}
+		e, err := encode(d, UTF16LE)
+		if err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if e = append(append(enc.bom(), e...), odd...); !bytes.Equal(e, test.in) {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.in, e)
+		}
+	}
+
+	// Unpaired surrogates are kept as is in UTF-16
+	in := []byte("\x34\xd8a\x00")
+	d := decode(in, UTF16LE)
+	if len(d) != 2 || d[0] != 0xd834 || d[1] != 'a' {
+		t.Errorf("Expected an unpaired surrogate, but got %x", d)
+	}
+	if e, err := encode(d, UTF16LE); err != nil || !bytes.Equal(e, in) {
+		t.Errorf("Expected %q, but got %q, %v", in, e, err)
+	}
+	if _, err := encode(d, UTF8); err != ErrCannotEncode {
+		t.Errorf("Expected ErrCannotEncode, but got %v", err)
+	}
+	if _, err := encode([]rune("€"), Latin1); err != ErrCannotEncode {
+		t.Errorf("Expected ErrCannotEncode, but got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+func (b *buffer) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	text, enc, bom, odd := decodeFile(data)
+	if err := b.setText(text); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	b.encoding = enc
+	b.bom = bom
+	b.odd = odd
+	b.lock.Unlock()
+	b.recordDiskState(path, data)
+	return b.SetFileName(path)
+}
+
+// Decodes the contents of a file, returning the text along with its
+// encoding, whether it had a BOM and the trailing odd byte of UTF-16
+func decodeFile(data []byte) (text []rune, enc Encoding, bom bool, odd []byte) {
+	enc, bom = DetectEncoding(data)
+	if bom {
+		data = data[len(enc.bom()):]
+	}
+	if (enc == UTF16LE || enc == UTF16BE) && len(data)%2 == 1 {
+		odd = append(odd, data[len(data)-1])
+	}
+	return decode(data, enc), enc, bom, odd
+}
+
+// Returns the contents of the buffer encoded as they
+// would be written to a file, including any BOM
+func (b *buffer) encoded() ([]byte, error) {
+	enc, bom := b.Encoding(), b.HasBOM()
+	data, err := encode(b.text(), enc)
+	if err != nil {
+		return nil, err
+	}
+	if bom {
+		data = append(enc.bom(), data...)
+	}
+	b.lock.Lock()
+	odd := b.odd
+	b.lock.Unlock()
+	if enc == UTF16LE || enc == UTF16BE {
+		data = append(data, odd...)
+	}
+	return data, nil
+}
+
+func (b *buffer) Encoding() Encoding {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.encoding
+}
+
+func (b *buffer) SetEncoding(e Encoding) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.encoding = e
+}
+
+func (b *buffer) HasBOM() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.bom
+}
+
+func (b *buffer) SetBOM(bom bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.bom = bom
+}
+
+func (b *buffer) Undecodable() (ret []Region) {
+	for i, r := range b.SubstrR(Region{0, b.Size()}) {
+		if utf8.ValidRune(r) {
+			continue
+		}
+		if l := len(ret) - 1; l >= 0 && ret[l].B == i {
+			ret[l].B++
+		} else {
+			ret = append(ret, Region{i, i + 1})
+		}
+	}
+	return
+}
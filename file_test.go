@@ -0,0 +1,121 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSaveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		in   []byte
+		exp  string
+		enc  Encoding
+		bom  bool
+		le   LineEnding
+		bad  []Region
+		size int
+	}{
+		{[]byte("abc\ndef\n"), "abc\ndef\n", UTF8, false, LF, nil, 8},
+		{[]byte("\xef\xbb\xbfabc\r\nå\r\n"), "abc\nå\n", UTF8, true, CRLF, nil, 6},
+		{[]byte("\xff\xfea\x00\r\x00\n\x00\xe5\x00"), "a\nå", UTF16LE, true, CRLF, nil, 3},
+		{[]byte("\xfe\xff\x00a\x00\r\x00\xe5"), "a\nå", UTF16BE, true, CR, nil, 3},
+		{[]byte("\xff\xfea\x00\n\x00b"), "a\n", UTF16LE, true, LF, nil, 2},
+		{[]byte("\xff\xfea\x00A\xdc"), "", UTF16LE, true, LF, []Region{{1, 2}}, 2},
+		{[]byte("r\xe4k\nsm\xf6r\n"), "räk\nsmör\n", Latin1, false, LF, nil, 9},
+		{[]byte("\xc3\xa5\xff\xfe\nx\x80"), "", UTF8, false, LF, []Region{{1, 3}, {5, 6}}, 6},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, "test.txt")
+		if err := ioutil.WriteFile(path, test.in, 0600); err != nil {
+			t.Fatal(err)
+		}
+		b := NewBuffer()
+		if err := b.LoadFile(path); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+			continue
+		}
+		if b.FileName() != path {
+			t.Errorf("Test %d: Expected the file name to be %q, but got %q", i, path, b.FileName())
+		}
+		if b.Size() != test.size {
+			t.Errorf("Test %d: Expected size %d, but got %d", i, test.size, b.Size())
+		}
+		if s := b.Substr(Region{0, b.Size()}); test.exp != "" && s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		if e := b.Encoding(); e != test.enc {
+			t.Errorf("Test %d: Expected %s, but got %s", i, test.enc, e)
+		}
+		if b.HasBOM() != test.bom {
+			t.Errorf("Test %d: Expected BOM to be %v", i, test.bom)
+		}
+		if le := b.LineEnding(); le != test.le {
+			t.Errorf("Test %d: Expected %s, but got %s", i, test.le, le)
+		}
+		if r := b.Undecodable(); !reflect.DeepEqual(r, test.bad) {
+			t.Errorf("Test %d: Expected undecodable regions %v, but got %v", i, test.bad, r)
+		}
+
+		out := filepath.Join(dir, "out.txt")
+		if err := b.SaveFile(out); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if data, err := ioutil.ReadFile(out); err != nil {
+			t.Error(err)
+		} else if !bytes.Equal(data, test.in) {
+			t.Errorf("Test %d: Expected %q to be saved, but got %q", i, test.in, data)
+		}
+		if b.FileName() != out {
+			t.Errorf("Test %d: Expected the file name to be %q, but got %q", i, out, b.FileName())
+		}
+		b.Close()
+	}
+}
+
+func TestSaveFileEncoding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "a€\nb")
+	b.SetLineEnding(CRLF)
+	b.SetEncoding(UTF16BE)
+	b.SetBOM(true)
+	if err := b.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	exp := []byte("\xfe\xff\x00a\x20\xac\x00\r\x00\n\x00b")
+	if data, _ := ioutil.ReadFile(path); !bytes.Equal(data, exp) {
+		t.Errorf("Expected %q, but got %q", exp, data)
+	}
+
+	b.SetEncoding(Latin1)
+	if err := b.SaveFile(path); err != ErrCannotEncode {
+		t.Errorf("Expected ErrCannotEncode, but got %v", err)
+	}
+	if data, _ := ioutil.ReadFile(path); !bytes.Equal(data, exp) {
+		t.Errorf("Expected the file to be left alone, but got %q", data)
+	}
+
+	if err := b.LoadFile(filepath.Join(dir, "nonexistent")); err == nil {
+		t.Error("Expected an error loading a nonexistent file")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"sort"
+)
+
+const gap_size = 4 * 1024
+
+type (
+	// Gap buffer (http://en.wikipedia.org/wiki/Gap_buffer)
+	//
+	// The unused space, the gap, is kept where the last edit
+	// happened, so that consecutive edits close to each other
+	// only need to move the few runes in between.
+	gapBuffer struct {
+		data             []rune
+		gapStart, gapEnd int
+		// Sorted indices into data of all the newlines. They only
+		// need updating for the runes that the gap moves over.
+		lines []int
+	}
+)
+
+// Returns a new empty InnerBufferInterface implementation
+// storing its data in a gap buffer, which is fast for small
+// to medium sized buffers being edited in one place at a time.
+func NewGapBackend() InnerBufferInterface {
+	return &gapBuffer{}
+}
+
+func (b *gapBuffer) Close() {
+}
+
+func (b *gapBuffer) gap() int {
+	return b.gapEnd - b.gapStart
+}
+
+func (b *gapBuffer) Size() int {
+	return len(b.data) - b.gap()
+}
+
+// Returns the index into data of the given text position
+func (b *gapBuffer) raw(pos int) int {
+	if pos < b.gapStart {
+		return pos
+	}
+	return pos + b.gap()
+}
+
+// Returns the text position of the given index into data
+func (b *gapBuffer) pos(raw int) int {
+	if raw < b.gapStart {
+		return raw
+	}
+	return raw - b.gap()
+}
+
+// Adds delta to the newline indices in the range [a, e)
+func (b *gapBuffer) adjustLines(a, e, delta int) {
+	i := sort.SearchInts(b.lines, a)
+	for ; i < len(b.lines) && b.lines[i] < e; i++ {
+		b.lines[i] += delta
+	}
+}
+
+func (b *gapBuffer) moveGap(pos int) {
+	gap := b.gap()
+	if pos < b.gapStart {
+		n := b.gapStart - pos
+		copy(b.data[b.gapEnd-n:b.gapEnd], b.data[pos:b.gapStart])
+		b.adjustLines(pos, b.gapStart, gap)
+		b.gapStart -= n
+		b.gapEnd -= n
+	} else if pos > b.gapStart {
+		n := pos - b.gapStart
+		copy(b.data[b.gapStart:pos], b.data[b.gapEnd:b.gapEnd+n])
+		b.adjustLines(b.gapEnd, b.gapEnd+n, -gap)
+		b.gapStart += n
+		b.gapEnd += n
+	}
+}
+
+// Makes sure the gap can hold at least n runes
+func (b *gapBuffer) grow(n int) {
+	gap := b.gap()
+	if gap >= n {
+		return
+	}
+	size := b.Size()
+	alloc := (size + n + gap_size + size/2 + gap_size - 1) &^ (gap_size - 1)
+	data := make([]rune, alloc)
+	copy(data, b.data[:b.gapStart])
+	end := alloc - (len(b.data) - b.gapEnd)
+	copy(data[end:], b.data[b.gapEnd:])
+	b.adjustLines(b.gapEnd, len(b.data), end-b.gapEnd)
+	b.data = data
+	b.gapEnd = end
+}
+
+func (b *gapBuffer) Index(pos int) rune {
+	if pos < 0 || pos >= b.Size() {
+		panic(fmt.Sprintf("Index out of bounds: %d >= %d", pos, b.Size()))
+	}
+	return b.data[b.raw(pos)]
+}
+
+func (b *gapBuffer) SubstrR(r Region) []rune {
+	l := b.Size()
+	a, e := Clamp(0, l, r.Begin()), Clamp(0, l, r.End())
+	data := make([]rune, 0, e-a)
+	if a < b.gapStart {
+		data = append(data, b.data[a:Min(e, b.gapStart)]...)
+	}
+	if e > b.gapStart {
+		data = append(data, b.data[b.raw(Max(a, b.gapStart)):b.raw(e)]...)
+	}
+	return data
+}
+
+func (b *gapBuffer) InsertR(point int, value []rune) error {
+	point = Clamp(0, b.Size(), point)
+	b.grow(len(value))
+	b.moveGap(point)
+	copy(b.data[b.gapStart:], value)
+
+	var nl []int
+	for i, r := range value {
+		if r == '\n' {
+			nl = append(nl, b.gapStart+i)
+		}
+	}
+	if len(nl) > 0 {
+		i := sort.SearchInts(b.lines, b.gapStart)
+		b.lines = append(b.lines[:i], append(nl, b.lines[i:]...)...)
+	}
+	b.gapStart += len(value)
+	return nil
+}
+
+func (b *gapBuffer) Erase(point, length int) error {
+	point = Clamp(0, b.Size(), point)
+	length = Clamp(0, b.Size()-point, length)
+	if length == 0 {
+		return nil
+	}
+	b.moveGap(point)
+	i := sort.SearchInts(b.lines, b.gapEnd)
+	j := sort.SearchInts(b.lines, b.gapEnd+length)
+	b.lines = append(b.lines[:i], b.lines[j:]...)
+	b.gapEnd += length
+	return nil
+}
+
+func (b *gapBuffer) RowCol(point int) (row, col int) {
+	point = Clamp(0, b.Size(), point)
+	raw := point
+	if point > b.gapStart {
+		raw += b.gap()
+	}
+	row = sort.SearchInts(b.lines, raw)
+	if row == 0 {
+		return row, point
+	}
+	return row, point - b.pos(b.lines[row-1]) - 1
+}
+
+func (b *gapBuffer) TextPoint(row, col int) (i int) {
+	if row == 0 && col == 0 {
+		return 0
+	}
+	if row > len(b.lines) {
+		return b.Size()
+	} else if row > 0 {
+		i = b.pos(b.lines[row-1]) + 1
+	}
+	if i < b.Size() {
+		return i + col
+	}
+	return i
+}
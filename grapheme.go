@@ -0,0 +1,252 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"unicode"
+)
+
+// Grapheme cluster break properties, as defined by
+// http://www.unicode.org/reports/tr29/#Grapheme_Cluster_Break_Property_Values
+const (
+	gbOther = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+	gbExtendedPictographic
+)
+
+// Returns the grapheme cluster break property of the rune.
+//
+// The properties are derived from the general categories rather
+// than the full Unicode tables, which is close enough for the
+// text found in practice.
+func graphemeBreak(r rune) int {
+	switch {
+	case r == '\r':
+		return gbCR
+	case r == '\n':
+		return gbLF
+	case r == 0x200d:
+		return gbZWJ
+	case r == 0x200c, r >= 0x1f3fb && r <= 0x1f3ff, r >= 0xe0020 && r <= 0xe007f:
+		// ZWNJ, emoji modifiers and tags
+		return gbExtend
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return gbRegionalIndicator
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return gbL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return gbV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return gbT
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return gbLV
+		}
+		return gbLVT
+	case extendedPictographic(r):
+		return gbExtendedPictographic
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return gbExtend
+	case unicode.Is(unicode.Mc, r):
+		return gbSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cs, unicode.Zl, unicode.Zp, unicode.Cf):
+		return gbControl
+	}
+	return gbOther
+}
+
+// Approximates the Extended_Pictographic property,
+// which is mostly what emoji are made of
+func extendedPictographic(r rune) bool {
+	switch {
+	case r == 0xa9, r == 0xae, r == 0x203c, r == 0x2049, r == 0x2122, r == 0x2139,
+		r >= 0x2194 && r <= 0x2199, r == 0x21a9, r == 0x21aa, r == 0x231a, r == 0x231b,
+		r == 0x2328, r == 0x23cf, r >= 0x23e9 && r <= 0x23f3, r >= 0x23f8 && r <= 0x23fa,
+		r == 0x24c2, r == 0x25aa, r == 0x25ab, r == 0x25b6, r == 0x25c0, r >= 0x25fb && r <= 0x25fe,
+		r >= 0x2600 && r <= 0x27bf, r == 0x2934, r == 0x2935, r >= 0x2b05 && r <= 0x2b07,
+		r == 0x2b1b, r == 0x2b1c, r == 0x2b50, r == 0x2b55, r == 0x3030, r == 0x303d,
+		r == 0x3297, r == 0x3299, r >= 0x1f000 && r <= 0x1faff, r >= 0x1fc00 && r <= 0x1fffd:
+		return true
+	}
+	return false
+}
+
+// Returns the number of runes in the grapheme cluster
+// (http://www.unicode.org/reports/tr29/) at the start of data.
+func graphemeLen(data []rune) int {
+	if len(data) == 0 {
+		return 0
+	}
+	prev := graphemeBreak(data[0])
+	// Whether the cluster so far ends with an extended pictographic
+	// rune followed by any number of extending runes
+	pict := prev == gbExtendedPictographic
+	// Number of regional indicators at the end of the cluster so far
+	ri := 0
+	if prev == gbRegionalIndicator {
+		ri = 1
+	}
+	i := 1
+	for ; i < len(data); i++ {
+		cur := graphemeBreak(data[i])
+		if !graphemeJoins(prev, cur, pict, ri) {
+			break
+		}
+		switch cur {
+		case gbExtendedPictographic:
+			pict = true
+		case gbExtend:
+		case gbZWJ:
+			pict = pict && prev != gbZWJ
+		default:
+			pict = false
+		}
+		if cur == gbRegionalIndicator {
+			ri++
+		} else {
+			ri = 0
+		}
+		prev = cur
+	}
+	return i
+}
+
+// Returns whether there is no grapheme cluster boundary between
+// runes with the break properties prev and cur.
+func graphemeJoins(prev, cur int, pict bool, ri int) bool {
+	switch {
+	case prev == gbCR && cur == gbLF:
+		return true
+	case prev == gbCR, prev == gbLF, prev == gbControl, cur == gbCR, cur == gbLF, cur == gbControl:
+		return false
+	case prev == gbL && (cur == gbL || cur == gbV || cur == gbLV || cur == gbLVT):
+		return true
+	case (prev == gbLV || prev == gbV) && (cur == gbV || cur == gbT):
+		return true
+	case (prev == gbLVT || prev == gbT) && cur == gbT:
+		return true
+	case cur == gbExtend, cur == gbZWJ, cur == gbSpacingMark:
+		return true
+	case prev == gbZWJ && cur == gbExtendedPictographic:
+		return pict
+	case prev == gbRegionalIndicator && cur == gbRegionalIndicator:
+		return ri%2 == 1
+	}
+	return false
+}
+
+// Runes with the East Asian Width property Wide or Fullwidth
+// (http://www.unicode.org/reports/tr11/), along with the emoji
+// that are displayed as wide
+var eastAsianWide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f0, 1},
+		{0x23f3, 0x23f3, 1},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x267f, 1},
+		{0x2693, 0x2693, 1},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26ce, 1},
+		{0x26d4, 0x26d4, 1},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26f5, 1},
+		{0x26fa, 0x26fa, 1},
+		{0x26fd, 0x26fd, 1},
+		{0x2705, 0x2705, 1},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27b0, 1},
+		{0x27bf, 0x27bf, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x17000, 0x18aff, 1},
+		{0x1b000, 0x1b2ff, 1},
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f1e6, 0x1f1ff, 1},
+		{0x1f200, 0x1f251, 1},
+		{0x1f300, 0x1f64f, 1},
+		{0x1f680, 0x1f6ff, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f90c, 0x1f9ff, 1},
+		{0x1fa70, 0x1faff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// Returns the number of columns the rune takes up when displayed
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(eastAsianWide, r):
+		return 2
+	}
+	return 1
+}
+
+// Returns the number of columns the grapheme cluster takes up
+// when displayed, which is that of its first rune unless it is
+// turned into an emoji by a variation selector.
+func graphemeWidth(cluster []rune) int {
+	w := runeWidth(cluster[0])
+	if w == 1 {
+		for _, r := range cluster[1:] {
+			if r == 0xfe0f {
+				return 2
+			}
+		}
+	}
+	return w
+}
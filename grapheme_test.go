@@ -0,0 +1,72 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func graphemes(s string) (ret []string) {
+	data := []rune(s)
+	for len(data) > 0 {
+		l := graphemeLen(data)
+		ret = append(ret, string(data[:l]))
+		data = data[l:]
+	}
+	return
+}
+
+func TestGraphemeLen(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp []string
+	}{
+		{"", nil},
+		{"abc", []string{"a", "b", "c"}},
+		{"a\r\nb", []string{"a", "\r\n", "b"}},
+		{"\n\r", []string{"\n", "\r"}},
+		{"e\u0301\u0302x", []string{"e\u0301\u0302", "x"}},
+		{"\u0301a", []string{"\u0301", "a"}},
+		{"\u1100\u1161\u11A8\uAC00", []string{"\u1100\u1161\u11A8", "\uAC00"}},
+		{"\U0001F1F8\U0001F1EA\U0001F1F3", []string{"\U0001F1F8\U0001F1EA", "\U0001F1F3"}},
+		{"\U0001F468\u200D\U0001F469\u200D\U0001F467!", []string{"\U0001F468\u200D\U0001F469\u200D\U0001F467", "!"}},
+		{"\U0001F44D\U0001F3FD", []string{"\U0001F44D\U0001F3FD"}},
+		{"a\u200D\U0001F469", []string{"a\u200D", "\U0001F469"}},
+		{"\u0915\u093F", []string{"\u0915\u093F"}},
+		{"a\x00\u0301", []string{"a", "\x00", "\u0301"}},
+	}
+	for i, test := range tests {
+		if g := graphemes(test.in); !reflect.DeepEqual(g, test.exp) {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, g)
+		}
+	}
+}
+
+func TestGraphemeWidth(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp int
+	}{
+		{"a", 1},
+		{"e\u0301", 1},
+		{"\u0301", 0},
+		{"\x00", 0},
+		{"å", 1},
+		{"漢", 2},
+		{"각", 2},
+		{"Ａ", 2},
+		{"\U0001F600", 2},
+		{"\U0001F1F8\U0001F1EA", 2},
+		{"❤", 1},
+		{"❤\uFE0F", 2},
+		{"\U0001F469\u200D\U0001F467", 2},
+	}
+	for i, test := range tests {
+		if w := graphemeWidth([]rune(test.in)); w != test.exp {
+			t.Errorf("Test %d: Expected %q to be %d wide, but got %d", i, test.in, test.exp, w)
+		}
+	}
+}
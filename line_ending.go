@@ -0,0 +1,225 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// LineEnding is the sequence of characters ending the lines of a file.
+// Buffers always use "\n" internally, and only use their LineEnding
+// when the contents are written out.
+type LineEnding int
+
+const (
+	// "\n", used by Unix like systems
+	LF LineEnding = iota
+	// "\r\n", used by Windows
+	CRLF
+	// "\r", used by classic Mac OS
+	CR
+)
+
+func (le LineEnding) String() string {
+	switch le {
+	case LF:
+		return "LF"
+	case CRLF:
+		return "CRLF"
+	case CR:
+		return "CR"
+	}
+	return "LineEnding(?)"
+}
+
+// Returns the characters making up the line ending
+func (le LineEnding) Sequence() string {
+	switch le {
+	case CRLF:
+		return "\r\n"
+	case CR:
+		return "\r"
+	}
+	return "\n"
+}
+
+// A newline of a buffer which ended its line with
+// something else than the buffer's LineEnding in the
+// text it was read from
+type endingAt struct {
+	pos int
+	le  LineEnding
+}
+
+// Returns the most common line ending in the text,
+// or LF if the text doesn't contain any.
+func DetectLineEnding(text string) LineEnding {
+	var lf, crlf, cr int
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			lf++
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				crlf++
+				i++
+			} else {
+				cr++
+			}
+		}
+	}
+	return mostCommon(lf, crlf, cr)
+}
+
+func mostCommon(lf, crlf, cr int) LineEnding {
+	switch {
+	case crlf > lf && crlf >= cr:
+		return CRLF
+	case cr > lf && cr > crlf:
+		return CR
+	}
+	return LF
+}
+
+// Returns the line ending at the start of data, and its length
+func lineEndingOf(data []rune) (LineEnding, int) {
+	if data[0] == '\n' {
+		return LF, 1
+	} else if len(data) > 1 && data[1] == '\n' {
+		return CRLF, 2
+	}
+	return CR, 1
+}
+
+// Replaces all the line endings in data with "\n", reusing its storage,
+// and returns the result along with the most common line ending and
+// the newlines of the result that were any other line ending
+func normalizeLineEndings(data []rune) ([]rune, LineEnding, []endingAt) {
+	var counts [3]int
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\n' || data[i] == '\r' {
+			le, l := lineEndingOf(data[i:])
+			counts[le]++
+			i += l - 1
+		}
+	}
+	dominant := mostCommon(counts[LF], counts[CRLF], counts[CR])
+
+	var other []endingAt
+	out := data[:0]
+	for i := 0; i < len(data); i++ {
+		r := data[i]
+		if r == '\n' || r == '\r' {
+			le, l := lineEndingOf(data[i:])
+			if le != dominant {
+				other = append(other, endingAt{len(out), le})
+			}
+			r = '\n'
+			i += l - 1
+		}
+		out = append(out, r)
+	}
+	return out, dominant, other
+}
+
+// Moves the line endings that aren't the buffer's LineEnding
+// after the buffer has changed at point by delta runes,
+// forgetting those that were erased. b.lock must be held.
+func (b *buffer) adjustEndings(point, delta int) {
+	i := sort.Search(len(b.endings), func(i int) bool {
+		return b.endings[i].pos >= point
+	})
+	j := i
+	if delta < 0 {
+		for j < len(b.endings) && b.endings[j].pos < point-delta {
+			j++
+		}
+	}
+	b.endings = append(b.endings[:i], b.endings[j:]...)
+	for ; i < len(b.endings); i++ {
+		b.endings[i].pos += delta
+	}
+}
+
+// Replaces the contents of the buffer with the given data,
+// normalizing its line endings and detecting the line ending to use
+// when writing the buffer out. Lines that end differently keep
+// their line ending unless changed.
+func (b *buffer) setText(data []rune) error {
+	data, le, other := normalizeLineEndings(data)
+	if s := b.Size(); s > 0 {
+		if err := b.Erase(0, s); err != nil {
+			return err
+		}
+	}
+	if len(data) > 0 {
+		if err := b.InsertR(0, data); err != nil {
+			return err
+		}
+	}
+	b.lock.Lock()
+	b.lineEnding = le
+	b.endings = other
+	b.lock.Unlock()
+	return nil
+}
+
+// Returns the contents of the buffer, with the line endings replaced
+// by the buffer's LineEnding, or the one the line was read with
+func (b *buffer) text() []rune {
+	data := b.SubstrR(Region{0, b.Size()})
+	b.lock.Lock()
+	le := b.lineEnding
+	other := append([]endingAt(nil), b.endings...)
+	b.lock.Unlock()
+	if le == LF && len(other) == 0 {
+		return data
+	}
+	seq := []rune(le.Sequence())
+	ret := make([]rune, 0, len(data))
+	for i, r := range data {
+		if r != '\n' {
+			ret = append(ret, r)
+		} else if len(other) > 0 && other[0].pos == i {
+			ret = append(ret, []rune(other[0].le.Sequence())...)
+			other = other[1:]
+		} else {
+			ret = append(ret, seq...)
+		}
+	}
+	return ret
+}
+
+func (b *buffer) ReadFrom(r io.Reader) (int64, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), b.setText(decode(data, UTF8))
+}
+
+func (b *buffer) WriteTo(w io.Writer) (int64, error) {
+	data, err := encode(b.text(), UTF8)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (b *buffer) LineEnding() LineEnding {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.lineEnding
+}
+
+func (b *buffer) SetLineEnding(le LineEnding) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.lineEnding = le
+	b.endings = nil
+}
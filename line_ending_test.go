@@ -0,0 +1,131 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectLineEnding(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp LineEnding
+	}{
+		{"", LF},
+		{"abc", LF},
+		{"a\nb\n", LF},
+		{"a\r\nb\r\n", CRLF},
+		{"a\rb\r", CR},
+		{"a\r\nb\r\nc\n", CRLF},
+		{"a\r\nb\nc\n", LF},
+		{"a\rb\rc\r\n", CR},
+		{"a\r\nb\r", CRLF},
+	}
+	for i, test := range tests {
+		if le := DetectLineEnding(test.in); le != test.exp {
+			t.Errorf("Test %d: Expected %s, but got %s", i, test.exp, le)
+		}
+	}
+}
+
+func TestBufferLineEndings(t *testing.T) {
+	tests := []struct {
+		in, exp, out string
+		le           LineEnding
+	}{
+		{"a\nb\n", "a\nb\n", "a\nb\n", LF},
+		{"a\r\nb\r\nc", "a\nb\nc", "a\r\nb\r\nc", CRLF},
+		{"a\rb\r", "a\nb\n", "a\rb\r", CR},
+		{"a\r\nb\r\nc\rd\n", "a\nb\nc\nd\n", "a\r\nb\r\nc\rd\n", CRLF},
+		{"progress 10%\rprogress 100%\ndone\n", "progress 10%\nprogress 100%\ndone\n", "progress 10%\rprogress 100%\ndone\n", LF},
+	}
+	for i, test := range tests {
+		b := NewBuffer()
+		b.Insert(0, "old contents")
+		if n, err := b.ReadFrom(strings.NewReader(test.in)); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if n != int64(len(test.in)) {
+			t.Errorf("Test %d: Expected to read %d bytes, but read %d", i, len(test.in), n)
+		}
+		if le := b.LineEnding(); le != test.le {
+			t.Errorf("Test %d: Expected %s, but got %s", i, test.le, le)
+		}
+		if s := b.Substr(Region{0, b.Size()}); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		var out bytes.Buffer
+		if n, err := b.WriteTo(&out); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if n != int64(out.Len()) {
+			t.Errorf("Test %d: Expected %d bytes written, but got %d", i, out.Len(), n)
+		}
+		if s := out.String(); s != test.out {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.out, s)
+		}
+		b.Close()
+	}
+}
+
+func TestBufferLineEndingLines(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.ReadFrom(strings.NewReader("ab\r\ncd\r\n\r\nef"))
+
+	exp := []Region{{0, 2}, {3, 5}, {6, 6}, {7, 9}}
+	lines := b.Lines(Region{0, b.Size()})
+	if len(lines) != len(exp) {
+		t.Fatalf("Expected %v, but got %v", exp, lines)
+	}
+	for i, l := range lines {
+		if l != exp[i] {
+			t.Errorf("Expected line %d to be %v, but got %v", i, exp[i], l)
+		}
+		if r := b.Line(l.Begin()); r != l {
+			t.Errorf("Expected Line(%d) to be %v, but got %v", l.Begin(), l, r)
+		}
+		if r := b.FullLine(l.Begin()); r.Begin() != l.Begin() || (r.End() != l.End()+1 && i != len(exp)-1) {
+			t.Errorf("Expected FullLine(%d) to end after %v, but got %v", l.Begin(), l, r)
+		}
+		if row, col := b.RowCol(l.End()); row != i || col != l.Size() {
+			t.Errorf("Expected RowCol(%d) to be %d, %d, but got %d, %d", l.End(), i, l.Size(), row, col)
+		}
+	}
+
+	b.SetLineEnding(LF)
+	var out bytes.Buffer
+	b.WriteTo(&out)
+	if s := out.String(); s != "ab\ncd\n\nef" {
+		t.Errorf("Expected the line endings to be converted, but got %q", s)
+	}
+}
+
+func TestBufferMixedLineEndingEdits(t *testing.T) {
+	tests := []struct {
+		edit func(b Buffer)
+		out  string
+	}{
+		{func(b Buffer) {}, "a\r\nb\rc\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Insert(0, "x\n") }, "x\r\na\r\nb\rc\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Insert(4, "x") }, "a\r\nb\rxc\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Erase(3, 1) }, "a\r\nbc\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Erase(2, 3) }, "a\r\n\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Erase(0, 2) }, "b\rc\r\nd\ne\r\n"},
+		{func(b Buffer) { b.Insert(8, "\n") }, "a\r\nb\rc\r\nd\n\r\ne\r\n"},
+		{func(b Buffer) { b.SetLineEnding(CRLF) }, "a\r\nb\r\nc\r\nd\r\ne\r\n"},
+	}
+	for i, test := range tests {
+		b := NewBuffer()
+		b.ReadFrom(strings.NewReader("a\r\nb\rc\r\nd\ne\r\n"))
+		test.edit(b)
+		var out bytes.Buffer
+		b.WriteTo(&out)
+		if s := out.String(); s != test.out {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.out, s)
+		}
+		b.Close()
+	}
+}
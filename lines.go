@@ -0,0 +1,162 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+// Implemented by InnerBufferInterface implementations that keep
+// track of the newlines in their parts, so that lines can be looked
+// up without going through all the text before them.
+type lineIndexer interface {
+	// Returns the number of newlines
+	newlines() int
+	// Returns the number of newlines before the rune offset pos
+	linesBefore(pos int) int
+	// Returns the rune offset right after the row'th newline,
+	// which must exist
+	lineStart(row int) int
+}
+
+func (n *node) newlines() int {
+	return n.Lines()
+}
+
+func (n *node) linesBefore(pos int) (lines int) {
+	for {
+		if pos >= n.weight && n.right != nil {
+			pos -= n.weight
+			lines += n.lines
+			n = n.right
+		} else if n.left != nil {
+			n = n.left
+		} else {
+			return lines + n.leafLinesBefore(pos)
+		}
+	}
+}
+
+func (n *node) lineStart(row int) (pos int) {
+	for {
+		if row > n.lines && n.right != nil {
+			row -= n.lines
+			pos += n.weight
+			n = n.right
+		} else if n.left != nil {
+			n = n.left
+		} else {
+			return pos + n.leafLineStart(row)
+		}
+	}
+}
+
+func newlinesOf(bi InnerBufferInterface) int {
+	if li, ok := bi.(lineIndexer); ok {
+		return li.newlines()
+	}
+	row, _ := bi.RowCol(bi.Size())
+	return row
+}
+
+// Returns the line the text position is on
+func lineOf(bi InnerBufferInterface, pos int) int {
+	pos = Clamp(0, bi.Size(), pos)
+	if li, ok := bi.(lineIndexer); ok {
+		return li.linesBefore(pos)
+	}
+	row, _ := bi.RowCol(pos)
+	return row
+}
+
+// Returns the text position of the start of the given line,
+// or the size of the inner buffer if there is no such line
+func lineStartOf(bi InnerBufferInterface, row int) int {
+	if row <= 0 {
+		return 0
+	} else if row > newlinesOf(bi) {
+		return bi.Size()
+	}
+	if li, ok := bi.(lineIndexer); ok {
+		return li.lineStart(row)
+	}
+	return bi.TextPoint(row, 0)
+}
+
+// Returns the region of the given line, not including its newline
+func lineRegion(bi InnerBufferInterface, row int) Region {
+	r := Region{lineStartOf(bi, row), bi.Size()}
+	if row < newlinesOf(bi) {
+		r.B = lineStartOf(bi, row+1) - 1
+	}
+	return r
+}
+
+// Returns the region of the line at the given offset,
+// not including its newline
+func lineAt(bi InnerBufferInterface, offset int) Region {
+	if offset < 0 {
+		return Region{0, 0}
+	} else if s := bi.Size(); offset >= s {
+		return Region{s, s}
+	}
+	return lineRegion(bi, lineOf(bi, offset))
+}
+
+// Returns the regions of the lines intersecting r, not
+// including their newlines. The empty line at the end of
+// text ending with a newline is not included.
+func linesIn(bi InnerBufferInterface, r Region) (ret []Region) {
+	last, n := lineOf(bi, r.End()), newlinesOf(bi)
+	for row := lineOf(bi, r.Begin()); row <= last; row++ {
+		l := lineRegion(bi, row)
+		if row == n && l.Empty() {
+			break
+		}
+		ret = append(ret, l)
+	}
+	return
+}
+
+func (s *SerializedBuffer) line(offset int) Region {
+	s.ops <- func() interface{} { return lineAt(s.inner, offset) }
+	r := <-s.lockret
+	if r2, ok := r.(Region); ok {
+		return r2
+	}
+	return Region{}
+}
+
+func (s *SerializedBuffer) lines(re Region) []Region {
+	s.ops <- func() interface{} { return linesIn(s.inner, re) }
+	r := <-s.lockret
+	if r2, ok := r.([]Region); ok {
+		return r2
+	}
+	return nil
+}
+
+func (s *SerializedBuffer) newlines() int {
+	s.ops <- func() interface{} { return newlinesOf(s.inner) }
+	r := <-s.lockret
+	if r2, ok := r.(int); ok {
+		return r2
+	}
+	return 0
+}
+
+func (s *SerializedBuffer) lineStart(line int) int {
+	s.ops <- func() interface{} { return lineStartOf(s.inner, line) }
+	r := <-s.lockret
+	if r2, ok := r.(int); ok {
+		return r2
+	}
+	return 0
+}
+
+func (s *SerializedBuffer) lineOf(offset int) int {
+	s.ops <- func() interface{} { return lineOf(s.inner, offset) }
+	r := <-s.lockret
+	if r2, ok := r.(int); ok {
+		return r2
+	}
+	return 0
+}
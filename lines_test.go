@@ -0,0 +1,129 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, bk := range backends {
+		inner := bk.new()
+		var text []rune
+		for i := 0; i < 80; i++ {
+			// Long lines as well as lots of short ones
+			n := r.Intn(50)
+			if i%20 == 0 {
+				n = 1000
+			}
+			data := make([]rune, n)
+			for j := range data {
+				data[j] = []rune("ab\nå")[r.Intn(4)]
+			}
+			if i%20 == 10 {
+				data = []rune(strings.Repeat("x", 1500))
+			}
+			pos := r.Intn(len(text) + 1)
+			inner.InsertR(pos, data)
+			text = append(text[:pos], append(data, text[pos:]...)...)
+			if i%3 == 0 && len(text) > 0 {
+				pos = r.Intn(len(text))
+				l := Min(r.Intn(100), len(text)-pos)
+				inner.Erase(pos, l)
+				text = append(text[:pos], text[pos+l:]...)
+			}
+		}
+
+		var starts []int
+		starts = append(starts, 0)
+		for i, ru := range text {
+			if ru == '\n' {
+				starts = append(starts, i+1)
+			}
+		}
+		if n := newlinesOf(inner); n != len(starts)-1 {
+			t.Errorf("%s: Expected %d newlines, but got %d", bk.name, len(starts)-1, n)
+		}
+		for row, s := range starts {
+			if p := lineStartOf(inner, row); p != s {
+				t.Errorf("%s: Expected line %d to start at %d, but got %d", bk.name, row, s, p)
+			}
+		}
+		if p := lineStartOf(inner, len(starts)); p != len(text) {
+			t.Errorf("%s: Expected the size for a line past the end, but got %d", bk.name, p)
+		}
+		for row, s := range starts {
+			// The start and end of every line, and somewhere in between
+			end := len(text)
+			if row+1 < len(starts) {
+				end = starts[row+1] - 1
+			}
+			for _, pos := range []int{s, (s + end) / 2, end} {
+				if l := lineOf(inner, pos); l != row {
+					t.Errorf("%s: Expected %d to be on line %d, but got %d", bk.name, pos, row, l)
+				}
+			}
+		}
+		inner.Close()
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	tests := []struct {
+		text  string
+		count int
+		lines []Region
+	}{
+		{"", 1, nil},
+		{"a", 1, []Region{{0, 1}}},
+		{"a\n", 2, []Region{{0, 1}}},
+		{"a\n\nbc", 3, []Region{{0, 1}, {2, 2}, {3, 5}}},
+		{"\n\n", 3, []Region{{0, 0}, {1, 1}}},
+	}
+	for i, test := range tests {
+		b.Erase(0, b.Size())
+		b.Insert(0, test.text)
+		if c := b.LineCount(); c != test.count {
+			t.Errorf("Test %d: Expected %d lines, but got %d", i, test.count, c)
+		}
+		if l := b.Lines(Region{0, b.Size()}); !reflect.DeepEqual(l, test.lines) {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.lines, l)
+		}
+		for j, l := range test.lines {
+			if s := b.LineStart(j); s != l.A {
+				t.Errorf("Test %d: Expected line %d to start at %d, but got %d", i, j, l.A, s)
+			}
+			if r := b.LineOf(l.B); r != j {
+				t.Errorf("Test %d: Expected %d to be on line %d, but got %d", i, l.B, j, r)
+			}
+			if r := b.Line(l.A); l.A < b.Size() && r != l {
+				t.Errorf("Test %d: Expected %v, but got %v", i, l, r)
+			}
+		}
+	}
+
+	b.Erase(0, b.Size())
+	b.Insert(0, "one\ntwo\nthree")
+	s := b.Snapshot()
+	b.Erase(0, 4)
+	if l := s.Line(5); l != (Region{4, 7}) {
+		t.Errorf("Expected the snapshot's line to be unchanged, but got %v", l)
+	}
+	if l := b.Lines(Region{2, 5}); !reflect.DeepEqual(l, []Region{{0, 3}, {4, 9}}) {
+		t.Errorf("Unexpected lines %v", l)
+	}
+	if s := b.LineStart(-1); s != 0 {
+		t.Errorf("Expected 0, but got %d", s)
+	}
+	if s := b.LineStart(5); s != b.Size() {
+		t.Errorf("Expected %d, but got %d", b.Size(), s)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+type (
+	// MergeResult is the outcome of a three-way merge. Its buffer
+	// holds the merged text, where each conflict holds our side
+	// of it until resolved. The conflict regions are kept up to
+	// date as the buffer is modified.
+	MergeResult struct {
+		buffer    Buffer
+		conflicts []conflict
+	}
+
+	// A conflict of a MergeResult. The region is kept next to the
+	// text of the sides, rather than in a RegionSet, as a RegionSet
+	// merges regions that come to overlap.
+	conflict struct {
+		region Region
+		// The base, our and their text
+		sides [3][]rune
+	}
+)
+
+// Returns the text of side, which was diffed against base giving the
+// hunks, that corresponds to the region r of base. The hunks must all
+// be within r.
+func sideText(base, side []rune, r Region, hunks []Hunk) []rune {
+	if len(hunks) == 0 {
+		return base[r.A:r.B]
+	}
+	first, last := hunks[0], hunks[len(hunks)-1]
+	return side[first.B.A-(first.A.A-r.A) : last.B.B+(r.B-last.A.B)]
+}
+
+// Merge merges the changes made in ours and theirs since base,
+// comparing them line by line. Where both have changed the same
+// lines of base differently, or lines right next to each other,
+// there is a conflict.
+func Merge(base, ours, theirs Buffer) *MergeResult {
+	tb := base.SubstrR(Region{0, base.Size()})
+	to := ours.SubstrR(Region{0, ours.Size()})
+	tt := theirs.SubstrR(Region{0, theirs.Size()})
+	ho, ht := Diff(base, ours), Diff(base, theirs)
+
+	m := &MergeResult{}
+	var (
+		text []rune
+		pos  int
+	)
+	for len(ho) > 0 || len(ht) > 0 {
+		// Grouping together all the hunks of both sides
+		// that overlap or touch, starting with the first one
+		var r Region
+		if len(ht) == 0 || (len(ho) > 0 && ho[0].A.A <= ht[0].A.A) {
+			r = ho[0].A
+		} else {
+			r = ht[0].A
+		}
+		var o, t int
+		for {
+			if o < len(ho) && ho[o].A.A <= r.B {
+				r.B = Max(r.B, ho[o].A.B)
+				o++
+			} else if t < len(ht) && ht[t].A.A <= r.B {
+				r.B = Max(r.B, ht[t].A.B)
+				t++
+			} else {
+				break
+			}
+		}
+
+		text = append(text, tb[pos:r.A]...)
+		so := sideText(tb, to, r, ho[:o])
+		st := sideText(tb, tt, r, ht[:t])
+		if o == 0 || t == 0 || string(so) == string(st) {
+			// Only one side changed these lines, or both
+			// changed them the same way
+			if o == 0 {
+				so = st
+			}
+			text = append(text, so...)
+		} else {
+			m.conflicts = append(m.conflicts, conflict{
+				Region{len(text), len(text) + len(so)},
+				[3][]rune{tb[r.A:r.B], so, st},
+			})
+			text = append(text, so...)
+		}
+		pos = r.B
+		ho, ht = ho[o:], ht[t:]
+	}
+	text = append(text, tb[pos:]...)
+
+	m.buffer = NewBuffer()
+	m.buffer.InsertR(0, text)
+	m.buffer.AddObserver(m)
+	return m
+}
+
+// Buffer returns the buffer holding the merged text.
+func (m *MergeResult) Buffer() Buffer {
+	return m.buffer
+}
+
+// Conflicts returns a copy of the regions of the buffer that are in
+// conflict. As the RegionSet merges regions that overlap, which
+// conflicts can come to do as the buffer is modified, use Len and
+// Conflict rather than indexing it to get at a given conflict.
+func (m *MergeResult) Conflicts() *RegionSet {
+	var rs RegionSet
+	for _, c := range m.conflicts {
+		rs.Add(c.region)
+	}
+	return &rs
+}
+
+// Len returns the number of unresolved conflicts.
+func (m *MergeResult) Len() int {
+	return len(m.conflicts)
+}
+
+// Conflict returns the region of the buffer of the i:th conflict.
+func (m *MergeResult) Conflict(i int) Region {
+	return m.conflicts[i].region
+}
+
+// Base returns the text of the i:th conflict as it is in the base.
+func (m *MergeResult) Base(i int) string {
+	return string(m.conflicts[i].sides[0])
+}
+
+// Ours returns our text of the i:th conflict.
+func (m *MergeResult) Ours(i int) string {
+	return string(m.conflicts[i].sides[1])
+}
+
+// Theirs returns their text of the i:th conflict.
+func (m *MergeResult) Theirs(i int) string {
+	return string(m.conflicts[i].sides[2])
+}
+
+// Resolve replaces the i:th conflict with the given text, after which
+// it is no longer a conflict, returning the applied action. Undoing
+// the action restores the text, but not the conflict.
+func (m *MergeResult) Resolve(i int, text string) Action {
+	r := m.conflicts[i].region
+	m.conflicts = append(m.conflicts[:i], m.conflicts[i+1:]...)
+
+	a := NewReplaceAction(m.buffer, r, text)
+	a.Apply()
+	return a
+}
+
+func (m *MergeResult) Erased(_ Buffer, r Region, _ []rune) {
+	for i := range m.conflicts {
+		m.conflicts[i].region.Adjust(r.End(), -r.Size())
+	}
+}
+
+func (m *MergeResult) Inserted(_ Buffer, r Region, _ []rune) {
+	for i := range m.conflicts {
+		m.conflicts[i].region.Adjust(r.Begin(), r.Size())
+	}
+}
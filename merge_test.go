@@ -0,0 +1,132 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		base, ours, theirs string
+		exp                string
+		conflicts          []Region
+		sides              [][3]string
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nc\n", nil, nil},
+		// Changes to different lines
+		{"a\nb\nc\nd\n", "A\nb\nc\nd\n", "a\nb\nc\nD\n", "A\nb\nc\nD\n", nil, nil},
+		{"a\nb\nc\n", "a\nb\nc\nd\n", "a\nc\n", "a\nc\nd\n", nil, nil},
+		// The same change on both sides
+		{"a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", "a\nB\nc\n", nil, nil},
+		// Conflicting changes
+		{"a\nb\nc\n", "a\nours\nc\n", "a\ntheirs\nc\n", "a\nours\nc\n",
+			[]Region{{2, 7}}, [][3]string{{"b\n", "ours\n", "theirs\n"}}},
+		{"a\nb\nc\n", "a\nc\n", "a\nB\nc\n", "a\nc\n",
+			[]Region{{2, 2}}, [][3]string{{"b\n", "", "B\n"}}},
+		// Changes next to each other conflict too
+		{"a\nb\nc\n", "A\nb\nc\n", "a\nB\nc\n", "A\nb\nc\n",
+			[]Region{{0, 4}}, [][3]string{{"a\nb\n", "A\nb\n", "a\nB\n"}}},
+		{"a\nb\nc\nd\ne\n", "1\nb\nc\nd\n5\n", "one\nb\nc\nd\nfive\n", "1\nb\nc\nd\n5\n",
+			[]Region{{0, 2}, {8, 10}}, [][3]string{{"a\n", "1\n", "one\n"}, {"e\n", "5\n", "five\n"}}},
+		{"", "a\n", "b\n", "a\n", []Region{{0, 2}}, [][3]string{{"", "a\n", "b\n"}}},
+	}
+	for i, test := range tests {
+		var bufs [3]Buffer
+		for j, s := range []string{test.base, test.ours, test.theirs} {
+			bufs[j] = NewBuffer()
+			bufs[j].Insert(0, s)
+		}
+		m := Merge(bufs[0], bufs[1], bufs[2])
+		if s := m.Buffer().Substr(Region{0, m.Buffer().Size()}); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		if r := m.Conflicts().Regions(); len(r)+len(test.conflicts) > 0 && !reflect.DeepEqual(r, test.conflicts) {
+			t.Errorf("Test %d: Expected conflicts %v, but got %v", i, test.conflicts, r)
+		}
+		for j, exp := range test.sides {
+			if s := [3]string{m.Base(j), m.Ours(j), m.Theirs(j)}; s != exp {
+				t.Errorf("Test %d: Expected conflict %d to be %q, but got %q", i, j, exp, s)
+			}
+		}
+		for _, b := range bufs {
+			b.Close()
+		}
+		m.Buffer().Close()
+	}
+}
+
+func TestMergeResolve(t *testing.T) {
+	var bufs [3]Buffer
+	for i, s := range []string{"a\nb\nc\nd\ne\n", "1\nb\nc\nd\n5\n", "one\nb\nc\nd\nfive\n"} {
+		bufs[i] = NewBuffer()
+		bufs[i].Insert(0, s)
+		defer bufs[i].Close()
+	}
+	m := Merge(bufs[0], bufs[1], bufs[2])
+	b := m.Buffer()
+	defer b.Close()
+
+	// The conflicts follow changes to the buffer
+	b.Insert(4, "x")
+	if r := m.Conflicts().Regions(); !reflect.DeepEqual(r, []Region{{0, 2}, {9, 11}}) {
+		t.Errorf("Unexpected conflicts %v", r)
+	}
+
+	a := m.Resolve(0, "one and 1\n")
+	if s := b.Substr(Region{0, b.Size()}); s != "one and 1\nb\nxc\nd\n5\n" {
+		t.Errorf("Unexpected text %q", s)
+	}
+	if r := m.Conflicts().Regions(); !reflect.DeepEqual(r, []Region{{17, 19}}) {
+		t.Errorf("Unexpected conflicts %v", r)
+	}
+	if m.Theirs(0) != "five\n" {
+		t.Errorf("Expected the remaining conflict to be the last one, but got %q", m.Theirs(0))
+	}
+
+	a.Undo()
+	if s := b.Substr(Region{0, b.Size()}); s != "1\nb\nxc\nd\n5\n" {
+		t.Errorf("Unexpected text %q", s)
+	}
+}
+
+func TestMergeCollapsedConflicts(t *testing.T) {
+	var bufs [3]Buffer
+	for i, s := range []string{"a\nb\nc\nd\ne\n", "a\nc\ne\n", "a\nB\nc\nD\ne\n"} {
+		bufs[i] = NewBuffer()
+		bufs[i].Insert(0, s)
+		defer bufs[i].Close()
+	}
+	m := Merge(bufs[0], bufs[1], bufs[2])
+	b := m.Buffer()
+	defer b.Close()
+	if m.Len() != 2 || m.Conflict(0) != (Region{2, 2}) || m.Conflict(1) != (Region{4, 4}) {
+		t.Fatalf("Unexpected conflicts %v", m.Conflicts().Regions())
+	}
+
+	// Erasing what lies between the conflicts leaves them both where it was
+	b.Erase(2, 2)
+	if m.Len() != 2 || m.Conflict(0) != (Region{2, 2}) || m.Conflict(1) != (Region{2, 2}) {
+		t.Errorf("Unexpected conflicts %v and %v", m.Conflict(0), m.Conflict(1))
+	}
+	if r := m.Conflicts().Regions(); !reflect.DeepEqual(r, []Region{{2, 2}}) {
+		t.Errorf("Unexpected conflicts %v", r)
+	}
+
+	m.Resolve(0, "X\n")
+	if s := b.Substr(Region{0, b.Size()}); s != "a\nX\ne\n" {
+		t.Errorf("Unexpected text %q", s)
+	}
+	if m.Len() != 1 || m.Theirs(0) != "D\n" {
+		t.Errorf("Expected the remaining conflict to be the second one, but got %q", m.Theirs(0))
+	}
+
+	// Changing the returned RegionSet doesn't change the conflicts
+	m.Conflicts().Clear()
+	if m.Len() != 1 {
+		t.Errorf("Expected 1 conflict, but got %d", m.Len())
+	}
+}
@@ -12,6 +12,13 @@ type (
 	}
 )
 
+// Returns a new empty InnerBufferInterface implementation
+// storing its data in a single slice. It's simple and fast
+// to read from, but every edit copies the data after it.
+func NewNaiveBackend() InnerBufferInterface {
+	return &naiveBuffer{}
+}
+
 func (b *naiveBuffer) Close() {
 }
 
@@ -26,7 +33,9 @@ func (buf *naiveBuffer) Index(pos int) rune {
 func (buf *naiveBuffer) SubstrR(r Region) []rune {
 	l := len(buf.data)
 	a, b := Clamp(0, l, r.Begin()), Clamp(0, l, r.End())
-	return buf.data[a:b]
+	// Returning a copy, as callers such as Buffer observers might
+	// hold on to the data while the buffer is being modified
+	return append([]rune(nil), buf.data[a:b]...)
 }
 
 func (buf *naiveBuffer) InsertR(point int, value []rune) error {
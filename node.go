@@ -17,17 +17,42 @@ type (
 		weight, lines int
 		left, right   *node
 		data          []rune
+		// Number of UTF-8 bytes and UTF-16 code units
+		// in the runes counted by weight
+		bytes, utf16 int
+		// The data of a leaf encoded as UTF-8, which a rope
+		// stores instead of runes if it was created with
+		// NewUTF8RopeBackend. All the leaves of a rope use the
+		// same storage.
+		text []byte
 	}
 	rebalancingNode struct {
 		node
 		patches int
+		// Whether the leaves store UTF-8
+		utf8 bool
 	}
 )
 
+// Returns a new empty InnerBufferInterface implementation
+// storing its data in a rope, which handles edits anywhere
+// in large buffers well. This is the backend used by NewBuffer.
+func NewRopeBackend() InnerBufferInterface {
+	return &rebalancingNode{}
+}
+
 func (n *node) Close() {
 
 }
 
+// dup returns a shallow copy of the node. The mutating operations
+// call it on a child before changing it, so that a node is never
+// modified in place once it might be shared with a snapshot.
+func (n *node) dup() *node {
+	c := *n
+	return &c
+}
+
 func (n *node) clone() *node {
 	var lc, rc *node
 	if n.left != nil {
@@ -36,12 +61,12 @@ func (n *node) clone() *node {
 	if n.right != nil {
 		rc = n.right.clone()
 	}
-	return &node{n.weight, n.lines, lc, rc, n.data}
+	return &node{n.weight, n.lines, lc, rc, n.data, n.bytes, n.utf16, n.text}
 }
 
 func (n *node) dump(indent string) string {
 	indent += "\t"
-	ret := fmt.Sprintf("%d, %s\n%sleft: ", n.weight, string(n.data), indent)
+	ret := fmt.Sprintf("%d, %s\n%sleft: ", n.weight, string(n.leafRunes(0)), indent)
 	if n.left == nil {
 		ret += "nil\n"
 	} else {
@@ -63,7 +88,7 @@ func (n *node) Index(pos int) rune {
 		if n.left != nil {
 			return n.left.Index(pos)
 		}
-		return n.data[pos]
+		return n.leafIndex(pos)
 	}
 	panic(fmt.Sprintf("Index out of bounds: %d >= %d", pos, n.weight))
 }
@@ -72,8 +97,8 @@ func (n node) String() string {
 	ret := ""
 	if n.left != nil {
 		ret += n.left.String()
-	} else if len(n.data) != 0 {
-		ret += string(n.data)
+	} else if n.leafLen() != 0 {
+		ret += string(n.leafRunes(0))
 	}
 	if n.right != nil {
 		ret += n.right.String()
@@ -89,11 +114,11 @@ func (n *node) SubstrR(r Region) []rune {
 	data := make([]rune, 0, l)
 	for l > 0 {
 		inner, off := n.find(a)
-		if inner == nil || off >= len(inner.data) {
+		if inner == nil || off >= inner.leafLen() {
 			break
 		}
-		r := Clamp(0, l, len(inner.data[off:]))
-		data = append(data, inner.data[off:off+r]...)
+		r := Clamp(0, l, inner.leafLen()-off)
+		data = inner.appendLeaf(data, off, off+r)
 		a += r
 		l -= r
 	}
@@ -129,13 +154,9 @@ func (n *node) rc(pos int) (row, col int) {
 	} else if n.left != nil {
 		return n.left.rc(pos)
 	}
-	for i := 0; i < pos && i < len(n.data); i++ {
-		if n.data[i] == '\n' {
-			row++
-			col = 0
-		} else {
-			col++
-		}
+	col = Clamp(0, n.leafLen(), pos)
+	if row = n.leafLinesBefore(pos); row > 0 {
+		col -= n.leafLineStart(row)
 	}
 	return
 }
@@ -157,11 +178,8 @@ func (n *node) TextPoint(row, col int) (i int) {
 
 	var n2 *node
 	n2, i, row = n.findline(0, row)
-	for l, o := n2.Size(), 0; row > 0 && o < l; o++ {
-		if n2.data[o] == '\n' {
-			row--
-		}
-		i++
+	if row > 0 {
+		i += n2.leafLineStart(row)
 	}
 	if i < n.Size() {
 		return i + col
@@ -192,16 +210,19 @@ func (n *node) simplify() {
 	if n.empty() {
 		n.lines = 0
 		n.weight = 0
+		n.bytes = 0
+		n.utf16 = 0
 		n.left = nil
 		n.right = nil
 	} else if n.weight < merge && (n.left != nil && n.left.leaf()) && (n.right != nil && n.right.leaf()) {
+		n.left = n.left.dup()
 		n.left.join(n.right)
 		*n = *n.left
 	}
 }
 
 func (n *node) empty() bool {
-	return len(n.data) == 0 && n.le() && (n.re())
+	return n.leafSize() == 0 && n.le() && (n.re())
 }
 
 func (n *node) re() bool {
@@ -231,44 +252,79 @@ func linecount(data []rune) (ret int) {
 func newNodeEx(data []rune, split int) *node {
 	if len(data) > split {
 		half := len(data) / 2
+		b, u := runeLengths(data[:half])
 		return &node{half,
 			linecount(data[:half]),
 			newNodeEx(data[:half], split),
 			newNodeEx(data[half:], split),
 			nil,
+			b, u,
+			nil,
 		}
 	}
-	return &node{len(data), linecount(data), nil, nil, data}
+	b, u := runeLengths(data)
+	return &node{len(data), linecount(data), nil, nil, data, b, u, nil}
 }
 
 func newNode(data []rune) *node {
 	return newNodeEx(data, merge)
 }
 
+// Returns a rope holding the data, with the kind of leaves this rope uses
+func (n *rebalancingNode) newNode(data []rune) *node {
+	if n.utf8 {
+		return newUTF8Node(data)
+	}
+	return newNode(data)
+}
+
 func (n *node) patch() {
 	n.simplify()
 	if n.left != nil {
 		n.weight = n.left.Size()
 		n.lines = n.left.Lines()
-		if n.right != nil && n.right.left != nil && n.left.leaf() && n.right.left.leaf() && n.weight+n.right.weight < merge {
+		n.bytes, n.utf16 = n.left.units(n.weight)
+		if n.right != nil && n.right.left != nil && n.left.leaf() && n.right.left.leaf() && fits(n.left, n.right.left) {
+			n.right = n.right.dup()
 			r := n.right.split(n.right.weight)
 			n.simplify()
 			n.concat(r)
 		}
+	} else if n.text != nil {
+		n.weight, n.lines, n.utf16 = utf8Counts(n.text)
+		n.bytes = len(n.text)
 	} else {
 		n.weight = len(n.data)
 		n.lines = linecount(n.data)
+		n.bytes, n.utf16 = runeLengths(n.data)
 	}
 }
 
+// Returns whether the two leaves are small enough to be joined into
+// one, counting the runes they store or the bytes of UTF-8 leaves
+func fits(a, b *node) bool {
+	if a.text != nil || b.text != nil {
+		return a.bytes+b.bytes < merge
+	}
+	return a.weight+b.weight < merge
+}
+
 func (n *node) split(pos int) (right *node) {
 	if n.weight < pos {
+		n.right = n.right.dup()
 		return n.right.split(pos - n.weight)
 	}
 	if n.left != nil {
+		n.left = n.left.dup()
 		right = n.left.split(pos)
 	} else if n.right != nil {
 		panic("shouldn't get here")
+	} else if n.text != nil {
+		off := runeOffset(n.text, pos)
+		right = newUTF8NodeEx(n.text[off:], merge)
+		n.text = n.text[:off:off]
+		n.patch()
+		return right
 	} else {
 		right = newNode(n.data[pos:])
 		n.data = n.data[:pos]
@@ -276,7 +332,7 @@ func (n *node) split(pos int) (right *node) {
 		return right
 	}
 	if n.right != nil {
-		right = &node{right.weight, right.lines, right, n.right, nil}
+		right = &node{right.weight, right.lines, right, n.right, nil, right.bytes, right.utf16, nil}
 	}
 	n.right = nil
 	n.patch()
@@ -303,22 +359,70 @@ func (n *node) Size() int {
 	return ret
 }
 
+// Returns the number of UTF-8 bytes and UTF-16
+// code units before the rune offset point
+func (n *node) units(point int) (bytes, utf16 int) {
+	for {
+		if point >= n.weight && n.right != nil {
+			point -= n.weight
+			bytes += n.bytes
+			utf16 += n.utf16
+			n = n.right
+		} else if n.left != nil {
+			n = n.left
+		} else {
+			b, u := n.leafUnits(point)
+			return bytes + b, utf16 + u
+		}
+	}
+}
+
+// Returns the rune offset of the rune containing the given offset
+// in UTF-8 bytes, or in UTF-16 code units if utf16 is true
+func (n *node) pointFrom(off int, utf16 bool) (pos int) {
+	for {
+		w := n.bytes
+		if utf16 {
+			w = n.utf16
+		}
+		if off >= w && n.right != nil {
+			off -= w
+			pos += n.weight
+			n = n.right
+		} else if n.left != nil {
+			n = n.left
+		} else {
+			return pos + n.leafPointFrom(off, utf16)
+		}
+	}
+}
+
 func (n *node) join(other *node) {
-	if len(n.data)+len(other.data) > merge {
+	if n.leafSize()+other.leafSize() > merge {
 		left := *n
 		n.left = &left
 		n.right = other
 		n.data = nil
+		n.text = nil
 		n.weight = n.left.Size()
 		n.lines = n.left.Lines()
+		n.bytes, n.utf16 = n.left.units(n.weight)
 	} else {
 		// Allocating a new buffer as other nodes might have references
 		// into sub positions in the original
-		nd := make([]rune, 0, merge)
-		n.data = append(nd, n.data...)
-		n.data = append(n.data, other.data...)
+		if n.text != nil || other.text != nil {
+			nd := make([]byte, 0, merge)
+			n.text = append(nd, n.text...)
+			n.text = append(n.text, other.text...)
+		} else {
+			nd := make([]rune, 0, merge)
+			n.data = append(nd, n.data...)
+			n.data = append(n.data, other.data...)
+		}
 		n.weight += other.weight
 		n.lines += other.lines
+		n.bytes += other.bytes
+		n.utf16 += other.utf16
 	}
 }
 
@@ -332,10 +436,14 @@ func (n *node) concat(other *node) {
 				// If the left argument is a concatenation node whose right son is a short leaf,
 				// and the right argument is also a short leaf,
 				// then we concatenate the two leaves, and then concatenate the result to the left son of the left argument.
+				n.right = n.right.dup()
 				n.right.concat(other)
+				n.left = n.left.dup()
 				n.left.concat(n.right)
 				n.right = nil
 			} else {
+				n.right = n.right.dup()
+				n.right.right = n.right.right.dup()
 				n.right.right.concat(other)
 			}
 		} else {
@@ -345,6 +453,7 @@ func (n *node) concat(other *node) {
 		left := *n
 		n.left = &left
 		n.data = nil
+		n.text = nil
 		n.right = other
 	}
 
@@ -352,9 +461,14 @@ func (n *node) concat(other *node) {
 }
 
 func (n *node) InsertR(position int, r []rune) error {
+	n.insert(position, newNode(r))
+	return nil
+}
+
+// Inserts the rope left at the given position
+func (n *node) insert(position int, left *node) {
 	l := n.Size()
 	position = Clamp(0, l, position)
-	left := newNode(r)
 	if position >= l {
 		n.concat(left)
 	} else {
@@ -362,7 +476,6 @@ func (n *node) InsertR(position int, r []rune) error {
 		n.concat(left)
 		n.concat(right)
 	}
-	return nil
 }
 
 func (n *node) Erase(position, length int) error {
@@ -375,13 +488,28 @@ func (n *node) Erase(position, length int) error {
 func (n *rebalancingNode) rebalance(add int) {
 	n.patches += add
 	if n.patches >= rebalance {
-		n.node = *newNode(n.SubstrR(Region{0, n.Size()}))
+		if n.utf8 {
+			// Straight from the bytes, rather than
+			// decoding everything into runes first
+			b, _ := n.units(n.Size())
+			n.node = *newUTF8NodeEx(n.appendText(make([]byte, 0, b)), merge)
+		} else {
+			n.node = *n.newNode(n.SubstrR(Region{0, n.Size()}))
+		}
 		n.patches = 0
 	}
 }
 
+// snapshot returns a read-only view of the rope as it is right now.
+// As InsertR and Erase copy every node along the path they modify,
+// later edits never touch the nodes reachable from the returned root.
+func (n *rebalancingNode) snapshot() InnerBufferInterface {
+	root := n.node
+	return &root
+}
+
 func (n *rebalancingNode) InsertR(position int, r []rune) error {
-	n.node.InsertR(position, r)
+	n.node.insert(position, n.newNode(r))
 	n.rebalance(len(r))
 	return nil
 }
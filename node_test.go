@@ -18,12 +18,14 @@ var complexnode_test = &node{
 			9, 0,
 			&node{
 				6, 0,
-				&node{6, 0, nil, nil, []rune("Hello ")},
-				&node{3, 0, nil, nil, []rune("my ")},
+				&node{6, 0, nil, nil, []rune("Hello "), 6, 6, nil},
+				&node{3, 0, nil, nil, []rune("my "), 3, 3, nil},
 				nil,
+				6, 6, nil,
 			},
 			nil,
 			nil,
+			9, 9, nil,
 		},
 		&node{
 			7, 0,
@@ -31,20 +33,25 @@ var complexnode_test = &node{
 				6, 0,
 				&node{
 					2, 0,
-					&node{2, 0, nil, nil, []rune("na")},
-					&node{4, 0, nil, nil, []rune("me i")},
+					&node{2, 0, nil, nil, []rune("na"), 2, 2, nil},
+					&node{4, 0, nil, nil, []rune("me i"), 4, 4, nil},
 					nil,
+					2, 2, nil,
 				},
-				&node{1, 0, nil, nil, []rune("s")},
+				&node{1, 0, nil, nil, []rune("s"), 1, 1, nil},
 				nil,
+				6, 6, nil,
 			},
-			&node{6, 0, nil, nil, []rune(" Simon")},
+			&node{6, 0, nil, nil, []rune(" Simon"), 6, 6, nil},
 			nil,
+			7, 7, nil,
 		},
 		nil,
+		9, 9, nil,
 	},
 	nil,
 	nil,
+	22, 22, nil,
 }
 
 type Test struct {
@@ -54,9 +61,9 @@ type Test struct {
 
 var (
 	tests = []Test{
-		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello ")}, &node{5, 0, nil, nil, []rune("world")}, nil}, "Hello world"},
-		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello ")}, &node{3, 0, &node{3, 0, nil, nil, []rune("wor")}, &node{2, 0, nil, nil, []rune("ld")}, nil}, nil}, "Hello world"},
-		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello ")}, &node{5, 0, nil, nil, []rune("world")}, nil}, "Hello world"},
+		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello "), 6, 6, nil}, &node{5, 0, nil, nil, []rune("world"), 5, 5, nil}, nil, 6, 6, nil}, "Hello world"},
+		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello "), 6, 6, nil}, &node{3, 0, &node{3, 0, nil, nil, []rune("wor"), 3, 3, nil}, &node{2, 0, nil, nil, []rune("ld"), 2, 2, nil}, nil, 3, 3, nil}, nil, 6, 6, nil}, "Hello world"},
+		{&node{6, 0, &node{6, 0, nil, nil, []rune("Hello "), 6, 6, nil}, &node{5, 0, nil, nil, []rune("world"), 5, 5, nil}, nil, 6, 6, nil}, "Hello world"},
 		{complexnode_test, "Hello my name is Simon"},
 	}
 	merges = []int{4, 8, 32, 128, 1024, merge}
@@ -83,14 +90,14 @@ func TestNode(t *testing.T) {
 }
 
 func TestNodeSimplify(t *testing.T) {
-	r := &node{5, 0, nil, nil, []rune("world")}
-	l := &node{0, 0, nil, nil, nil}
-	n := node{0, 0, l, r, nil}
+	r := &node{5, 0, nil, nil, []rune("world"), 5, 5, nil}
+	l := &node{0, 0, nil, nil, nil, 0, 0, nil}
+	n := node{0, 0, l, r, nil, 0, 0, nil}
 	n.simplify()
 	if !reflect.DeepEqual(&n, r) {
 		t.Error(n.dump(""))
 	}
-	n = node{5, 0, r, l, nil}
+	n = node{5, 0, r, l, nil, 5, 5, nil}
 	n.simplify()
 	if !reflect.DeepEqual(&n, r) {
 		t.Error(n.dump(""))
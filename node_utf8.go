@@ -0,0 +1,250 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"unicode/utf8"
+)
+
+// Returns a new empty InnerBufferInterface implementation storing
+// its data in a rope like the one returned by NewRopeBackend, but
+// with UTF-8 encoded leaves. All positions are still rune offsets,
+// as each node keeps the number of runes, newlines, bytes and UTF-16
+// code units to its left, but for text that is mostly ASCII it needs
+// about a quarter of the memory.
+//
+// Runes that aren't valid Unicode code points are stored as
+// utf8.RuneError, with the exception of surrogate halves which are
+// kept as is so that data that couldn't be decoded survives a round trip.
+func NewUTF8RopeBackend() InnerBufferInterface {
+	return &rebalancingNode{utf8: true}
+}
+
+// Encodes the rune into p, which must be large enough, and returns
+// the number of bytes written. Unlike utf8.EncodeRune, surrogate
+// halves are encoded with their generalized UTF-8 three byte sequence.
+func encodeRune(p []byte, r rune) int {
+	if r >= 0xd800 && r <= 0xdfff {
+		p[0] = 0xe0 | byte(r>>12)
+		p[1] = 0x80 | byte(r>>6)&0x3f
+		p[2] = 0x80 | byte(r)&0x3f
+		return 3
+	}
+	return utf8.EncodeRune(p, r)
+}
+
+// The inverse of encodeRune
+func decodeRune(p []byte) (rune, int) {
+	if p[0] < utf8.RuneSelf {
+		return rune(p[0]), 1
+	}
+	if len(p) >= 3 && p[0] == 0xed && p[1] >= 0xa0 && p[1] <= 0xbf && p[2]&0xc0 == 0x80 {
+		return rune(p[0]&0x0f)<<12 | rune(p[1]&0x3f)<<6 | rune(p[2]&0x3f), 3
+	}
+	return utf8.DecodeRune(p)
+}
+
+func encodeRunes(data []rune) []byte {
+	ret := make([]byte, 0, len(data))
+	var tmp [utf8.UTFMax]byte
+	for _, r := range data {
+		if r < utf8.RuneSelf && r >= 0 {
+			ret = append(ret, byte(r))
+		} else {
+			ret = append(ret, tmp[:encodeRune(tmp[:], r)]...)
+		}
+	}
+	return ret
+}
+
+// Returns the byte offset of the rune at offset pos in p,
+// or len(p) if there aren't that many runes
+func runeOffset(p []byte, pos int) (i int) {
+	for ; pos > 0 && i < len(p); pos-- {
+		_, l := decodeRune(p[i:])
+		i += l
+	}
+	return
+}
+
+// Returns the number of runes, newlines and UTF-16 code units in p
+func utf8Counts(p []byte) (runes, lines, utf16 int) {
+	for i := 0; i < len(p); runes++ {
+		if c := p[i]; c < utf8.RuneSelf {
+			if c == '\n' {
+				lines++
+			}
+			i++
+		} else {
+			_, l := decodeRune(p[i:])
+			if l == 4 {
+				utf16++
+			}
+			i += l
+		}
+	}
+	utf16 += runes
+	return
+}
+
+func newUTF8NodeEx(data []byte, split int) *node {
+	if len(data) > split {
+		half := len(data) / 2
+		for half < len(data) && !utf8.RuneStart(data[half]) {
+			half++
+		}
+		if half < len(data) {
+			runes, lines, utf16 := utf8Counts(data[:half])
+			return &node{runes,
+				lines,
+				newUTF8NodeEx(data[:half], split),
+				newUTF8NodeEx(data[half:], split),
+				nil,
+				half, utf16,
+				nil,
+			}
+		}
+	}
+	runes, lines, utf16 := utf8Counts(data)
+	return &node{runes, lines, nil, nil, nil, len(data), utf16, data}
+}
+
+// Returns a rope with UTF-8 leaves holding the runes
+func newUTF8Node(data []rune) *node {
+	return newUTF8NodeEx(encodeRunes(data), merge)
+}
+
+// Appends the UTF-8 encoded contents of the rope to p
+func (n *node) appendText(p []byte) []byte {
+	if n == nil {
+		return p
+	}
+	if n.text != nil {
+		p = append(p, n.text...)
+	} else if n.data != nil {
+		p = append(p, encodeRunes(n.data)...)
+	}
+	return n.right.appendText(n.left.appendText(p))
+}
+
+// The functions below work on leaves, whether they
+// store their data as runes or as UTF-8
+
+// Returns the number of runes in the leaf
+func (n *node) leafLen() int {
+	if n.text != nil {
+		return n.weight
+	}
+	return len(n.data)
+}
+
+// Returns the number of runes or bytes stored in the leaf
+func (n *node) leafSize() int {
+	return len(n.data) + len(n.text)
+}
+
+// Returns the runes from a to the end of the leaf. The returned
+// slice might be the leaf's own data and must not be modified.
+func (n *node) leafRunes(a int) []rune {
+	if n.text == nil {
+		return n.data[a:]
+	}
+	return n.appendLeaf(nil, a, n.weight)
+}
+
+// Appends the runes from a to b of the leaf to data
+func (n *node) appendLeaf(data []rune, a, b int) []rune {
+	if n.text == nil {
+		return append(data, n.data[a:b]...)
+	}
+	for i := runeOffset(n.text, a); a < b; a++ {
+		r, l := decodeRune(n.text[i:])
+		data = append(data, r)
+		i += l
+	}
+	return data
+}
+
+func (n *node) leafIndex(pos int) rune {
+	if n.text == nil {
+		return n.data[pos]
+	}
+	r, _ := decodeRune(n.text[runeOffset(n.text, pos):])
+	return r
+}
+
+// Returns the number of newlines before the rune offset pos of the leaf
+func (n *node) leafLinesBefore(pos int) int {
+	if n.text == nil {
+		return linecount(n.data[:Clamp(0, len(n.data), pos)])
+	}
+	_, lines, _ := utf8Counts(n.text[:runeOffset(n.text, pos)])
+	return lines
+}
+
+// Returns the rune offset right after the row'th newline
+// of the leaf, or the end of the leaf if there is none
+func (n *node) leafLineStart(row int) int {
+	if n.text == nil {
+		for i, r := range n.data {
+			if r == '\n' {
+				if row--; row == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(n.data)
+	}
+	pos := 0
+	for i := 0; i < len(n.text); pos++ {
+		c := n.text[i]
+		if c >= utf8.RuneSelf {
+			_, l := decodeRune(n.text[i:])
+			i += l
+			continue
+		}
+		if i++; c == '\n' {
+			if row--; row == 0 {
+				return pos + 1
+			}
+		}
+	}
+	return pos
+}
+
+// Returns the number of UTF-8 bytes and UTF-16 code
+// units before the rune offset point of the leaf
+func (n *node) leafUnits(point int) (bytes, utf16 int) {
+	if n.text == nil {
+		return runeLengths(n.data[:Clamp(0, len(n.data), point)])
+	}
+	bytes = runeOffset(n.text, point)
+	_, _, utf16 = utf8Counts(n.text[:bytes])
+	return
+}
+
+// Returns the rune offset of the rune of the leaf containing the given
+// offset in UTF-8 bytes, or in UTF-16 code units if utf16 is true
+func (n *node) leafPointFrom(off int, utf16 bool) (pos int) {
+	if n.text == nil {
+		return runesBefore(n.data, off, utf16)
+	}
+	for i := 0; i < len(n.text); pos++ {
+		_, l := decodeRune(n.text[i:])
+		w := l
+		if utf16 {
+			w = 1
+			if l == 4 {
+				w = 2
+			}
+		}
+		if off < w {
+			break
+		}
+		off -= w
+		i += l
+	}
+	return
+}
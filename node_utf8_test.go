@@ -0,0 +1,133 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+// Checks that every node's counts match its children, and
+// that all the leaves store UTF-8 of at most merge bytes
+func checkUTF8Node(t *testing.T, n *node) {
+	if n.left == nil {
+		if n.data != nil || len(n.text) > merge {
+			t.Errorf("Bad leaf: %d runes, %d bytes", len(n.data), len(n.text))
+		}
+		if n.right != nil {
+			checkUTF8Node(t, n.right)
+		}
+		return
+	}
+	if b, u := n.left.units(n.left.Size()); n.weight != n.left.Size() || n.lines != n.left.Lines() || n.bytes != b || n.utf16 != u {
+		t.Errorf("Counts don't add up: %d, %d, %d, %d", n.weight, n.lines, n.bytes, n.utf16)
+	}
+	checkUTF8Node(t, n.left)
+	if n.right != nil {
+		checkUTF8Node(t, n.right)
+	}
+}
+
+// Returns the number of bytes stored in the leaves
+func utf8Size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return len(n.text) + utf8Size(n.left) + utf8Size(n.right)
+}
+
+func TestUTF8RopeLeaves(t *testing.T) {
+	data, err := ioutil.ReadFile("./testdata/unittest.cpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewUTF8RopeBackend().(*rebalancingNode)
+	exp := []rune(string(data))
+	r.InsertR(0, exp)
+	checkUTF8Node(t, &r.node)
+	if s := utf8Size(&r.node); s != len(data) {
+		t.Errorf("Expected %d bytes, but got %d", len(data), s)
+	}
+	for i := 0; i < 500; i++ {
+		p := rand.Intn(len(exp) + 1)
+		if i%3 == 0 {
+			l := rand.Intn(Min(len(exp)-Min(p, len(exp)-1), 2048))
+			r.Erase(p, l)
+			exp = append(exp[:p:p], exp[Min(p+l, len(exp)):]...)
+		} else {
+			in := make([]rune, 1+rand.Intn(8))
+			fill(in)
+			r.InsertR(p, in)
+			exp = append(exp[:p:p], append(in, exp[p:]...)...)
+		}
+		checkUTF8Node(t, &r.node)
+	}
+	checkBackend(t, -1, r, exp)
+}
+
+func TestUTF8RopeRunes(t *testing.T) {
+	r := NewUTF8RopeBackend()
+	in := []rune{'a', 'å', '€', '𝄞', 0xdc80, 0xdcff, 0xd800, '\n', 'b'}
+	r.InsertR(0, in)
+	if r.Size() != len(in) {
+		t.Errorf("Expected size %d, but got %d", len(in), r.Size())
+	}
+	if s := r.SubstrR(Region{0, r.Size()}); string(s) != string(in) {
+		t.Errorf("Expected %q, but got %q", in, s)
+	} else {
+		for i := range in {
+			if s[i] != in[i] {
+				t.Errorf("Expected %x at %d, but got %x", in[i], i, s[i])
+			}
+			if c := r.Index(i); c != in[i] {
+				t.Errorf("Expected Index(%d) to be %x, but got %x", i, in[i], c)
+			}
+		}
+	}
+	if row, col := r.RowCol(len(in)); row != 1 || col != 1 {
+		t.Errorf("Expected 1:1, but got %d:%d", row, col)
+	}
+
+	// Not a valid code point, so stored as utf8.RuneError
+	r.InsertR(0, []rune{0x110000})
+	if c := r.Index(0); c != 0xfffd {
+		t.Errorf("Expected U+FFFD, but got %x", c)
+	}
+}
+
+func TestUTF8RopeSnapshot(t *testing.T) {
+	r := NewUTF8RopeBackend().(*rebalancingNode)
+	r.InsertR(0, []rune("hello world"))
+	s := r.snapshot()
+	r.Erase(0, 6)
+	r.InsertR(0, []rune("brave new "))
+	if v := string(s.SubstrR(Region{0, s.Size()})); v != "hello world" {
+		t.Errorf("Snapshot changed to %q", v)
+	}
+	if v := string(r.SubstrR(Region{0, r.Size()})); v != "brave new world" {
+		t.Errorf("Expected %q, but got %q", "brave new world", v)
+	}
+}
+
+func TestUTF8RopeRebalance(t *testing.T) {
+	r := NewUTF8RopeBackend().(*rebalancingNode)
+	var exp []rune
+	for i := 0; i < 300; i++ {
+		exp = append(exp, 'a', 'å', '€', '𝄞', 0xdc80, 0xd800, '\n')
+	}
+	r.InsertR(0, exp)
+	// Many small edits, rebalancing the rope several times
+	for i := 0; i < 3*rebalance; i++ {
+		p := rand.Intn(len(exp))
+		r.Erase(p, 1)
+		r.InsertR(p, exp[p:p+1])
+	}
+	checkUTF8Node(t, &r.node)
+	if s, b := utf8Size(&r.node), len(encodeRunes(exp)); s != b {
+		t.Errorf("Expected %d bytes, but got %d", b, s)
+	}
+	checkBackend(t, -1, r, exp)
+}
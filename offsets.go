@@ -0,0 +1,243 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"unicode/utf8"
+)
+
+// Implemented by InnerBufferInterface implementations that keep
+// enough bookkeeping to convert between rune offsets and UTF-8 or
+// UTF-16 offsets without going through all the text before them.
+type offsetConverter interface {
+	// Returns the number of UTF-8 bytes and UTF-16
+	// code units before the rune offset point
+	units(point int) (bytes, utf16 int)
+	// Returns the rune offset of the rune containing the given offset
+	// in UTF-8 bytes, or in UTF-16 code units if utf16 is true
+	pointFrom(off int, utf16 bool) int
+}
+
+// Returns the length of the rune in UTF-8 bytes, or in UTF-16 code
+// units if utf16 is true. Runes that can't be encoded take up as
+// much space as the utf8.RuneError they are encoded as.
+func runeLen(r rune, utf16 bool) int {
+	if utf16 {
+		if r >= 0x10000 && r <= utf8.MaxRune {
+			return 2
+		}
+		return 1
+	}
+	if l := utf8.RuneLen(r); l > 0 {
+		return l
+	}
+	return 3
+}
+
+func runeLengths(data []rune) (bytes, utf16 int) {
+	for _, r := range data {
+		bytes += runeLen(r, false)
+		utf16 += runeLen(r, true)
+	}
+	return
+}
+
+// Returns the number of runes in data that end at or before the given
+// offset in UTF-8 bytes, or in UTF-16 code units if utf16 is true
+func runesBefore(data []rune, off int, utf16 bool) (i int) {
+	for ; i < len(data); i++ {
+		l := runeLen(data[i], utf16)
+		if off < l {
+			break
+		}
+		off -= l
+	}
+	return
+}
+
+func unitsOf(bi InnerBufferInterface, point int) (bytes, utf16 int) {
+	point = Clamp(0, bi.Size(), point)
+	if c, ok := bi.(offsetConverter); ok {
+		return c.units(point)
+	}
+	return runeLengths(bi.SubstrR(Region{0, point}))
+}
+
+func pointFrom(bi InnerBufferInterface, off int, utf16 bool) int {
+	if off <= 0 {
+		return 0
+	}
+	if c, ok := bi.(offsetConverter); ok {
+		return c.pointFrom(off, utf16)
+	}
+	const chunk = 4 * 1024
+	s := bi.Size()
+	for pos := 0; pos < s; pos += chunk {
+		data := bi.SubstrR(Region{pos, pos + chunk})
+		if i := runesBefore(data, off, utf16); i < len(data) {
+			return pos + i
+		}
+		b, u := runeLengths(data)
+		if utf16 {
+			off -= u
+		} else {
+			off -= b
+		}
+	}
+	return s
+}
+
+// Returns the offset of the first newline at or after point,
+// or the size of the buffer if there is none
+func lineEnd(b InnerBufferInterface, point int) int {
+	return lineRegion(b, lineOf(b, point)).B
+}
+
+func graphemeRowCol(b InnerBufferInterface, point int) (row, col int) {
+	point = Clamp(0, b.Size(), point)
+	row, col = b.RowCol(point)
+	start := point - col
+	data := b.SubstrR(Region{start, lineEnd(b, point)})
+	col = 0
+	for i, n := 0, point-start; ; col++ {
+		l := graphemeLen(data[i:])
+		if l == 0 || i+l > n {
+			return
+		}
+		i += l
+	}
+}
+
+func graphemeTextPoint(b InnerBufferInterface, row, col int) int {
+	start := b.TextPoint(row, 0)
+	data := b.SubstrR(Region{start, lineEnd(b, start)})
+	i := 0
+	for ; col > 0 && i < len(data); col-- {
+		i += graphemeLen(data[i:])
+	}
+	return start + i
+}
+
+// Returns the display column following the grapheme cluster
+// when it is displayed starting at column col
+func advance(col int, cluster []rune, tabSize int) int {
+	if cluster[0] == '\t' {
+		return col + tabSize - col%tabSize
+	}
+	return col + graphemeWidth(cluster)
+}
+
+func visualRowCol(b InnerBufferInterface, point, tabSize int) (row, col int) {
+	point = Clamp(0, b.Size(), point)
+	row, col = b.RowCol(point)
+	start := point - col
+	data := b.SubstrR(Region{start, lineEnd(b, point)})
+	col = 0
+	for i, n := 0, point-start; i < len(data); {
+		l := graphemeLen(data[i:])
+		if i+l > n {
+			break
+		}
+		col = advance(col, data[i:i+l], tabSize)
+		i += l
+	}
+	return
+}
+
+func visualTextPoint(b InnerBufferInterface, row, col, tabSize int) int {
+	start := b.TextPoint(row, 0)
+	data := b.SubstrR(Region{start, lineEnd(b, start)})
+	i := 0
+	for c := 0; c < col && i < len(data); {
+		l := graphemeLen(data[i:])
+		if c = advance(c, data[i:i+l], tabSize); c > col {
+			break
+		}
+		i += l
+	}
+	return start + i
+}
+
+func (s *SerializedBuffer) units(point int) (bytes, utf16 int) {
+	s.ops <- func() interface{} { b, u := unitsOf(s.inner, point); return [2]int{b, u} }
+	r := <-s.lockret
+	if r2, ok := r.([2]int); ok {
+		return r2[0], r2[1]
+	}
+	return 0, 0
+}
+
+func (s *SerializedBuffer) pointFrom(off int, utf16 bool) int {
+	s.ops <- func() interface{} { return pointFrom(s.inner, off, utf16) }
+	r := <-s.lockret
+	if r2, ok := r.(int); ok {
+		return r2
+	}
+	return 0
+}
+
+func (b *buffer) ByteOffset(point int) int {
+	bytes, _ := b.units(point)
+	return bytes
+}
+
+func (b *buffer) PointFromByte(offset int) int {
+	return b.pointFrom(offset, false)
+}
+
+func (b *buffer) UTF16Offset(point int) int {
+	_, utf16 := b.units(point)
+	return utf16
+}
+
+func (b *buffer) PointFromUTF16(offset int) int {
+	return b.pointFrom(offset, true)
+}
+
+func (b *buffer) GraphemeRowCol(point int) (row, col int) {
+	return graphemeRowCol(b, point)
+}
+
+func (b *buffer) GraphemeTextPoint(row, col int) int {
+	return graphemeTextPoint(b, row, col)
+}
+
+func (b *buffer) tabSize() int {
+	return Max(1, b.Settings().Int("tab_size", 4))
+}
+
+func (b *buffer) VisualRowCol(point int) (row, col int) {
+	return visualRowCol(b, point, b.tabSize())
+}
+
+func (b *buffer) VisualTextPoint(row, col int) int {
+	return visualTextPoint(b, row, col, b.tabSize())
+}
+
+func (s *snapshot) ByteOffset(point int) int {
+	bytes, _ := unitsOf(s.inner, point)
+	return bytes
+}
+
+func (s *snapshot) PointFromByte(offset int) int {
+	return pointFrom(s.inner, offset, false)
+}
+
+func (s *snapshot) UTF16Offset(point int) int {
+	_, utf16 := unitsOf(s.inner, point)
+	return utf16
+}
+
+func (s *snapshot) PointFromUTF16(offset int) int {
+	return pointFrom(s.inner, offset, true)
+}
+
+func (s *snapshot) GraphemeRowCol(point int) (row, col int) {
+	return graphemeRowCol(s.inner, point)
+}
+
+func (s *snapshot) GraphemeTextPoint(row, col int) int {
+	return graphemeTextPoint(s.inner, row, col)
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+)
+
+func checkOffsets(t *testing.T, i int, bi InnerBufferInterface, exp []rune) {
+	for j := 0; j < 8; j++ {
+		p := rand.Intn(len(exp) + 1)
+		eb, eu := len(string(exp[:p])), len(utf16.Encode(exp[:p]))
+		if b, u := unitsOf(bi, p); b != eb || u != eu {
+			t.Fatalf("%d: Expected %d, %d at %d, but got %d, %d", i, eb, eu, p, b, u)
+		}
+		if q := pointFrom(bi, eb, false); q != p {
+			t.Fatalf("%d: Expected byte offset %d to be %d, but got %d", i, eb, p, q)
+		}
+		if q := pointFrom(bi, eu, true); q != p {
+			t.Fatalf("%d: Expected UTF-16 offset %d to be %d, but got %d", i, eu, p, q)
+		}
+		if p < len(exp) {
+			if l := runeLen(exp[p], false); l > 1 {
+				if q := pointFrom(bi, eb+l-1, false); q != p {
+					t.Fatalf("%d: Expected byte offset %d to be %d, but got %d", i, eb+l-1, p, q)
+				}
+			}
+		}
+	}
+}
+
+func TestOffsetConversions(t *testing.T) {
+	alphabet := []rune("ab€𝄞\nåö\U0001F600 ")
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			bi := be.new()
+			defer bi.Close()
+			var exp []rune
+			for i := 0; i < 300; i++ {
+				if p := rand.Intn(len(exp) + 1); len(exp) > 0 && rand.Intn(3) == 0 {
+					l := rand.Intn(len(exp) - Min(p, len(exp)-1))
+					bi.Erase(p, l)
+					exp = append(exp[:p:p], exp[Min(p+l, len(exp)):]...)
+				} else {
+					data := make([]rune, 1+rand.Intn(600))
+					for j := range data {
+						data[j] = alphabet[rand.Intn(len(alphabet))]
+					}
+					bi.InsertR(p, data)
+					exp = append(exp[:p:p], append(data, exp[p:]...)...)
+				}
+				checkOffsets(t, i, bi, exp)
+			}
+			eb, eu := len(string(exp)), len(utf16.Encode(exp))
+			if p := pointFrom(bi, eb+10, false); p != len(exp) {
+				t.Errorf("Expected %d past the end, but got %d", len(exp), p)
+			}
+			if p := pointFrom(bi, eu+10, true); p != len(exp) {
+				t.Errorf("Expected %d past the end, but got %d", len(exp), p)
+			}
+		})
+	}
+}
+
+func TestBufferOffsets(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "a€𝄞b\ne\u0301x🇸🇪🇳🇴y")
+
+	tests := []struct {
+		point, bytes, utf16 int
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{2, 4, 2},
+		{3, 8, 4},
+		{4, 9, 5},
+		{5, 10, 6},
+		{b.Size(), len(b.Substr(Region{0, b.Size()})), 18},
+	}
+	s := b.Snapshot()
+	for i, test := range tests {
+		if v := b.ByteOffset(test.point); v != test.bytes {
+			t.Errorf("Test %d: Expected ByteOffset %d, but got %d", i, test.bytes, v)
+		}
+		if v := b.UTF16Offset(test.point); v != test.utf16 {
+			t.Errorf("Test %d: Expected UTF16Offset %d, but got %d", i, test.utf16, v)
+		}
+		if v := b.PointFromByte(test.bytes); v != test.point {
+			t.Errorf("Test %d: Expected PointFromByte %d, but got %d", i, test.point, v)
+		}
+		if v := b.PointFromUTF16(test.utf16); v != test.point {
+			t.Errorf("Test %d: Expected PointFromUTF16 %d, but got %d", i, test.point, v)
+		}
+		if v := s.ByteOffset(test.point); v != test.bytes {
+			t.Errorf("Test %d: Expected snapshot ByteOffset %d, but got %d", i, test.bytes, v)
+		}
+		if v := s.PointFromUTF16(test.utf16); v != test.point {
+			t.Errorf("Test %d: Expected snapshot PointFromUTF16 %d, but got %d", i, test.point, v)
+		}
+	}
+	// In the middle of the surrogate pair encoding 𝄞
+	if v := b.PointFromUTF16(3); v != 2 {
+		t.Errorf("Expected 2, but got %d", v)
+	}
+
+	gtests := []struct {
+		point, row, col int
+	}{
+		{0, 0, 0},
+		{4, 0, 4},
+		{5, 1, 0},
+		{6, 1, 0},
+		{7, 1, 1},
+		{8, 1, 2},
+		{9, 1, 2},
+		{10, 1, 3},
+		{11, 1, 3},
+		{12, 1, 4},
+		{13, 1, 5},
+	}
+	for i, test := range gtests {
+		if r, c := b.GraphemeRowCol(test.point); r != test.row || c != test.col {
+			t.Errorf("Test %d: Expected %d, %d, but got %d, %d", i, test.row, test.col, r, c)
+		}
+		if r, c := s.GraphemeRowCol(test.point); r != test.row || c != test.col {
+			t.Errorf("Test %d: Expected %d, %d from the snapshot, but got %d, %d", i, test.row, test.col, r, c)
+		}
+	}
+	for col, exp := range []int{5, 7, 8, 10, 12, 13, 13} {
+		if p := b.GraphemeTextPoint(1, col); p != exp {
+			t.Errorf("Expected %d for column %d, but got %d", exp, col, p)
+		}
+	}
+}
+
+func TestBufferVisual(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "a\tb\n漢字x\n\te\u0301\t\U0001F469\u200D\U0001F467.")
+
+	tests := []struct {
+		tabSize, point, row, col int
+	}{
+		{4, 0, 0, 0},
+		{4, 1, 0, 1},
+		{4, 2, 0, 4},
+		{4, 3, 0, 5},
+		{8, 2, 0, 8},
+		{8, 3, 0, 9},
+		{4, 4, 1, 0},
+		{4, 5, 1, 2},
+		{4, 6, 1, 4},
+		{4, 7, 1, 5},
+		{4, 9, 2, 4},
+		{4, 10, 2, 4},
+		{4, 11, 2, 5},
+		{4, 12, 2, 8},
+		{4, 14, 2, 8},
+		{4, 15, 2, 10},
+		{2, 11, 2, 3},
+		{2, 12, 2, 4},
+	}
+	for i, test := range tests {
+		b.Settings().Set("tab_size", test.tabSize)
+		if r, c := b.VisualRowCol(test.point); r != test.row || c != test.col {
+			t.Errorf("Test %d: Expected %d, %d, but got %d, %d", i, test.row, test.col, r, c)
+		}
+	}
+
+	b.Settings().Set("tab_size", 4)
+	tests2 := []struct {
+		row, col, point int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0, 2, 1},
+		{0, 4, 2},
+		{0, 5, 3},
+		{0, 100, 3},
+		{1, 1, 4},
+		{1, 2, 5},
+		{1, 3, 5},
+		{1, 5, 7},
+		{2, 4, 9},
+		{2, 5, 11},
+		{2, 8, 12},
+		{2, 9, 12},
+		{2, 10, 15},
+		{2, 11, 16},
+	}
+	for i, test := range tests2 {
+		if p := b.VisualTextPoint(test.row, test.col); p != test.point {
+			t.Errorf("Test %d: Expected %d for %d, %d, but got %d", i, test.point, test.row, test.col, p)
+		}
+	}
+}
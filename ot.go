@@ -0,0 +1,288 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"sync"
+)
+
+type (
+	// Op is a single insert or erase operation, with the same shapes as
+	// the actions returned by NewInsertAction and NewEraseAction. It is
+	// the unit of the operational transformation
+	// (http://en.wikipedia.org/wiki/Operational_transformation) functions
+	// used to let multiple sites edit the same text concurrently.
+	//
+	// An Op with a non-empty Value inserts it at Point, otherwise
+	// Length units are erased from Point.
+	Op struct {
+		Point  int
+		Value  []rune
+		Length int
+	}
+
+	// OTTransport is used by an OTClient to send operations to the server.
+	//
+	// The server is expected to pass the operations to OTServer.Receive,
+	// acknowledge them to the sending client with OTClient.Ack, and
+	// broadcast the transformed operations it returns to all other clients
+	// through OTClient.ApplyRemote, preserving the order in which the
+	// server received them.
+	OTTransport interface {
+		Send(revision int, ops []Op)
+	}
+
+	// OTServer holds the authoritative history of operations. Operations
+	// sent by clients based on an older revision are transformed against
+	// everything that has been applied since.
+	OTServer struct {
+		buffer  Buffer
+		history [][]Op
+		lock    sync.Mutex
+	}
+
+	// OTClient keeps a local buffer in sync with an OTServer.
+	//
+	// At most one batch of local operations is in flight at any time.
+	// Local operations made while waiting for the server to acknowledge
+	// it are buffered and sent together when the acknowledgement arrives.
+	OTClient struct {
+		buffer    Buffer
+		transport OTTransport
+		revision  int
+		// Operations sent but not yet acknowledged by the server
+		pending []Op
+		// Operations not yet sent
+		buffered []Op
+		waiting  bool
+		lock     sync.Mutex
+	}
+)
+
+var (
+	ErrInvalidRevision = fmt.Errorf("Invalid revision")
+)
+
+// InsertOp returns an Op inserting value at point
+func InsertOp(point int, value string) Op {
+	return Op{Point: point, Value: []rune(value)}
+}
+
+// EraseOp returns an Op erasing the given region
+func EraseOp(r Region) Op {
+	return Op{Point: r.Begin(), Length: r.Size()}
+}
+
+func (o Op) String() string {
+	if o.isInsert() {
+		return fmt.Sprintf("insert %d %s", o.Point, string(o.Value))
+	}
+	return fmt.Sprintf("erase %v", Region{o.Point, o.Point + o.Length})
+}
+
+func (o Op) isInsert() bool {
+	return len(o.Value) > 0
+}
+
+// Action returns the Action performing this operation on the given buffer
+func (o Op) Action(b Buffer) Action {
+	if o.isInsert() {
+		return NewInsertAction(b, o.Point, string(o.Value))
+	}
+	return NewEraseAction(b, Region{o.Point, o.Point + o.Length})
+}
+
+// Apply performs the operation on the given buffer
+func (o Op) Apply(b Buffer) error {
+	if o.isInsert() {
+		return b.InsertR(o.Point, o.Value)
+	} else if o.Length > 0 {
+		return b.Erase(o.Point, o.Length)
+	}
+	return nil
+}
+
+// ApplyOps performs the operations on the given buffer in order
+func ApplyOps(b Buffer, ops []Op) error {
+	for _, o := range ops {
+		if err := o.Apply(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transform takes two sequences of operations a and b that were made
+// concurrently on the same text, and returns a' and b' such that
+// applying a followed by b' gives the same result as applying b
+// followed by a'.
+//
+// When both sides insert at the same point, b's insertion ends up
+// first. A server should thus pass the operations it has already
+// applied as b.
+func Transform(a, b []Op) (ap, bp []Op) {
+	if len(a) == 0 || len(b) == 0 {
+		return a, b
+	}
+	if len(a) == 1 && len(b) == 1 {
+		return transformOp(a[0], b[0])
+	}
+	if len(a) > 1 {
+		a1, b1 := Transform(a[:1], b)
+		a2, b2 := Transform(a[1:], b1)
+		return append(a1, a2...), b2
+	}
+	a1, b1 := Transform(a, b[:1])
+	a2, b2 := Transform(a1, b[1:])
+	return a2, append(b1, b2...)
+}
+
+func transformOp(a, b Op) (ap, bp []Op) {
+	switch {
+	case a.isInsert() && b.isInsert():
+		if a.Point < b.Point {
+			b.Point += len(a.Value)
+		} else {
+			a.Point += len(b.Value)
+		}
+		return []Op{a}, []Op{b}
+	case a.isInsert():
+		return transformInsertErase(a, b)
+	case b.isInsert():
+		bp, ap = transformInsertErase(b, a)
+		return
+	}
+	return eraseAfter(a, b), eraseAfter(b, a)
+}
+
+func transformInsertErase(ins, er Op) (insp, erp []Op) {
+	end := er.Point + er.Length
+	switch {
+	case ins.Point <= er.Point:
+		er.Point += len(ins.Value)
+	case ins.Point >= end:
+		ins.Point -= er.Length
+	default:
+		// The insertion is inside of the erased region. The inserted
+		// data is kept, and whatever is around it erased.
+		erp = []Op{
+			{Point: er.Point, Length: ins.Point - er.Point},
+			{Point: er.Point + len(ins.Value), Length: end - ins.Point},
+		}
+		ins.Point = er.Point
+		return []Op{ins}, erp
+	}
+	return []Op{ins}, []Op{er}
+}
+
+// Returns the erase a, adjusted to be applied after the erase b
+func eraseAfter(a, b Op) []Op {
+	move := func(p int) int {
+		if p <= b.Point {
+			return p
+		} else if p < b.Point+b.Length {
+			return b.Point
+		}
+		return p - b.Length
+	}
+	s, e := move(a.Point), move(a.Point+a.Length)
+	if s == e {
+		return nil
+	}
+	return []Op{{Point: s, Length: e - s}}
+}
+
+// NewOTServer returns a new OTServer at revision 0. If b isn't nil
+// the received operations are applied to it.
+func NewOTServer(b Buffer) *OTServer {
+	return &OTServer{buffer: b}
+}
+
+// Revision returns the number of operation batches the server has applied
+func (s *OTServer) Revision() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.history)
+}
+
+// Receive transforms operations made by a client at the given revision
+// against everything the server has applied since, and applies them.
+// It returns the transformed operations, which are to be broadcast to
+// the other clients, and the server's new revision.
+func (s *OTServer) Receive(revision int, ops []Op) ([]Op, int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if revision < 0 || revision > len(s.history) {
+		return nil, len(s.history), ErrInvalidRevision
+	}
+	for _, h := range s.history[revision:] {
+		ops, _ = Transform(ops, h)
+	}
+	if s.buffer != nil {
+		if err := ApplyOps(s.buffer, ops); err != nil {
+			return nil, len(s.history), err
+		}
+	}
+	s.history = append(s.history, ops)
+	return ops, len(s.history), nil
+}
+
+// NewOTClient returns a new OTClient for the given buffer, whose
+// contents are those of the server at the given revision.
+func NewOTClient(b Buffer, revision int, t OTTransport) *OTClient {
+	return &OTClient{buffer: b, revision: revision, transport: t}
+}
+
+// Revision returns the last server revision the client knows about
+func (c *OTClient) Revision() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.revision
+}
+
+// ApplyLocal applies operations made locally to the buffer, and
+// sends them to the server.
+func (c *OTClient) ApplyLocal(ops []Op) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := ApplyOps(c.buffer, ops); err != nil {
+		return err
+	}
+	if c.waiting {
+		c.buffered = append(c.buffered, ops...)
+		return nil
+	}
+	c.pending = ops
+	c.waiting = true
+	c.transport.Send(c.revision, ops)
+	return nil
+}
+
+// ApplyRemote applies operations broadcast by the server, transforming
+// them against local operations the server hasn't seen yet.
+func (c *OTClient) ApplyRemote(ops []Op) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending, ops = Transform(c.pending, ops)
+	c.buffered, ops = Transform(c.buffered, ops)
+	c.revision++
+	return ApplyOps(c.buffer, ops)
+}
+
+// Ack is called when the server has applied the operations
+// last sent by this client.
+func (c *OTClient) Ack() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.revision++
+	c.pending = nil
+	if len(c.buffered) == 0 {
+		c.waiting = false
+		return
+	}
+	c.pending, c.buffered = c.buffered, nil
+	c.transport.Send(c.revision, c.pending)
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func applyOpsString(s string, ops []Op) string {
+	r := []rune(s)
+	for _, o := range ops {
+		if o.isInsert() {
+			p := Clamp(0, len(r), o.Point)
+			r = append(r[:p], append(append([]rune(nil), o.Value...), r[p:]...)...)
+		} else if o.Length > 0 {
+			r = append(r[:o.Point], r[o.Point+o.Length:]...)
+		}
+	}
+	return string(r)
+}
+
+func randomOps(size, n int) (ret []Op) {
+	for i := 0; i < n; i++ {
+		if size > 0 && rand.Intn(2) == 0 {
+			p := rand.Intn(size)
+			l := 1 + rand.Intn(Min(size-p, 5))
+			ret = append(ret, Op{Point: p, Length: l})
+			size -= l
+		} else {
+			v := make([]rune, 1+rand.Intn(3))
+			fill(v)
+			ret = append(ret, Op{Point: rand.Intn(size + 1), Value: v})
+			size += len(v)
+		}
+	}
+	return
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		a, b   []Op
+		ap, bp []Op
+	}{
+		{
+			[]Op{InsertOp(1, "a")}, []Op{InsertOp(3, "b")},
+			[]Op{InsertOp(1, "a")}, []Op{InsertOp(4, "b")},
+		},
+		{
+			[]Op{InsertOp(3, "a")}, []Op{InsertOp(3, "b")},
+			[]Op{InsertOp(4, "a")}, []Op{InsertOp(3, "b")},
+		},
+		{
+			[]Op{InsertOp(3, "ab")}, []Op{EraseOp(Region{1, 5})},
+			[]Op{InsertOp(1, "ab")}, []Op{EraseOp(Region{1, 3}), EraseOp(Region{3, 5})},
+		},
+		{
+			[]Op{EraseOp(Region{0, 4})}, []Op{EraseOp(Region{2, 6})},
+			[]Op{EraseOp(Region{0, 2})}, []Op{EraseOp(Region{0, 2})},
+		},
+		{
+			[]Op{EraseOp(Region{2, 3})}, []Op{EraseOp(Region{0, 6})},
+			nil, []Op{EraseOp(Region{0, 5})},
+		},
+	}
+	for i, test := range tests {
+		ap, bp := Transform(test.a, test.b)
+		if !reflect.DeepEqual(ap, test.ap) || !reflect.DeepEqual(bp, test.bp) {
+			t.Errorf("%d: Expected %v, %v, but got %v, %v", i, test.ap, test.bp, ap, bp)
+		}
+	}
+
+	const init = "hello world, this is a test"
+	for i := 0; i < 1000; i++ {
+		a := randomOps(len(init), 1+rand.Intn(3))
+		b := randomOps(len(init), 1+rand.Intn(3))
+		ap, bp := Transform(a, b)
+		if ab, ba := applyOpsString(applyOpsString(init, a), bp), applyOpsString(applyOpsString(init, b), ap); ab != ba {
+			t.Fatalf("%d: %v, %v didn't converge: %q != %q", i, a, b, ab, ba)
+		}
+	}
+}
+
+type (
+	otMessage struct {
+		from     int
+		revision int
+		ops      []Op
+	}
+	// An in-process transport queueing messages to and from the server,
+	// which are delivered in random order between the clients.
+	fakeTransport struct {
+		id     int
+		server *[]otMessage
+	}
+)
+
+func (ft *fakeTransport) Send(revision int, ops []Op) {
+	*ft.server = append(*ft.server, otMessage{ft.id, revision, ops})
+}
+
+func TestOTClientServer(t *testing.T) {
+	const (
+		init    = "hello world"
+		clients = 3
+	)
+	sb := NewBuffer()
+	defer sb.Close()
+	sb.Insert(0, init)
+	server := NewOTServer(sb)
+
+	var (
+		toServer []otMessage
+		toClient [clients][]otMessage
+		cs       [clients]*OTClient
+	)
+	for i := range cs {
+		b := NewBuffer()
+		defer b.Close()
+		b.Insert(0, init)
+		cs[i] = NewOTClient(b, server.Revision(), &fakeTransport{i, &toServer})
+	}
+	serve := func() {
+		m := toServer[0]
+		toServer = toServer[1:]
+		ops, _, err := server.Receive(m.revision, m.ops)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range toClient {
+			toClient[i] = append(toClient[i], otMessage{m.from, 0, ops})
+		}
+	}
+	deliver := func(i int) {
+		m := toClient[i][0]
+		toClient[i] = toClient[i][1:]
+		if m.from == i {
+			cs[i].Ack()
+		} else if err := cs[i].ApplyRemote(m.ops); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for step := 0; step < 500; step++ {
+		i := rand.Intn(clients)
+		switch rand.Intn(3) {
+		case 0:
+			if err := cs[i].ApplyLocal(randomOps(cs[i].buffer.Size(), 1+rand.Intn(2))); err != nil {
+				t.Fatal(err)
+			}
+		case 1:
+			if len(toServer) > 0 {
+				serve()
+			}
+		case 2:
+			if len(toClient[i]) > 0 {
+				deliver(i)
+			}
+		}
+	}
+	for {
+		progress := false
+		if len(toServer) > 0 {
+			serve()
+			progress = true
+		}
+		for i := range cs {
+			if len(toClient[i]) > 0 {
+				deliver(i)
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+
+	exp := sb.Substr(Region{0, sb.Size()})
+	for i, c := range cs {
+		if d := c.buffer.Substr(Region{0, c.buffer.Size()}); d != exp {
+			t.Errorf("Client %d didn't converge: %q != %q", i, d, exp)
+		}
+		if r := c.Revision(); r != server.Revision() {
+			t.Errorf("Client %d at revision %d, server at %d", i, r, server.Revision())
+		}
+	}
+	if _, _, err := server.Receive(server.Revision()+1, nil); err != ErrInvalidRevision {
+		t.Errorf("Expected %v, but got %v", ErrInvalidRevision, err)
+	}
+}
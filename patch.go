@@ -0,0 +1,233 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	// A hunk of a unified diff
+	patchHunk struct {
+		// Where the hunk expects the old lines to start, as a
+		// zero based line index
+		pos int
+		// The lines the hunk replaces and what they are replaced
+		// with, including their line endings
+		old, new []string
+		// How many context lines the hunk starts and ends with
+		leading, trailing int
+		// Where the hunk is in the patch itself
+		region Region
+	}
+)
+
+// Parses the hunks of a unified diff. Everything outside of the
+// hunks, such as the file headers, is ignored.
+func parsePatch(patch string) (hunks []patchHunk, err error) {
+	var (
+		lines   []string
+		offsets []int
+		offset  int
+	)
+	for len(patch) > 0 {
+		i := strings.IndexByte(patch, '\n') + 1
+		if i == 0 {
+			i = len(patch)
+		}
+		lines = append(lines, strings.TrimSuffix(strings.TrimSuffix(patch[:i], "\n"), "\r"))
+		offsets = append(offsets, offset)
+		offset += utf8.RuneCountInString(patch[:i])
+		patch = patch[i:]
+	}
+	offsets = append(offsets, offset)
+
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "@@ ") {
+			continue
+		}
+		start, ol, nl, err := parseHunkHeader(lines[i])
+		if err != nil {
+			return nil, err
+		}
+		h := patchHunk{pos: start - 1, region: Region{offsets[i], 0}}
+		if ol == 0 {
+			// Pure insertions are after the given line
+			h.pos = start
+		}
+		changed := false
+		for i++; ol > 0 || nl > 0; i++ {
+			if i >= len(lines) {
+				return nil, fmt.Errorf("Patch ends in the middle of a hunk")
+			}
+			l := lines[i]
+			if l == "" {
+				// Some tools strip the space of empty context lines
+				l = " "
+			}
+			text := l[1:] + "\n"
+			switch l[0] {
+			case ' ':
+				h.old = append(h.old, text)
+				h.new = append(h.new, text)
+				ol--
+				nl--
+				if changed {
+					h.trailing++
+				} else {
+					h.leading++
+				}
+			case '-':
+				h.old = append(h.old, text)
+				ol--
+				changed, h.trailing = true, 0
+			case '+':
+				h.new = append(h.new, text)
+				nl--
+				changed, h.trailing = true, 0
+			default:
+				return nil, fmt.Errorf("Malformed hunk line: %q", lines[i])
+			}
+			if ol < 0 || nl < 0 {
+				return nil, fmt.Errorf("Hunk has more lines than its header says: %q", lines[i])
+			}
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+				// "\ No newline at end of file"
+				i++
+				if l[0] != '+' {
+					h.old[len(h.old)-1] = l[1:]
+				}
+				if l[0] != '-' {
+					h.new[len(h.new)-1] = l[1:]
+				}
+			}
+		}
+		if !changed {
+			h.leading, h.trailing = len(h.old), 0
+		}
+		h.region.B = offsets[i]
+		i--
+		hunks = append(hunks, h)
+	}
+	return
+}
+
+// Parses a hunk header like "@@ -1,4 +1,5 @@", returning the line
+// the hunk starts at in the old file, and its number of old and new lines
+func parseHunkHeader(l string) (start, ol, nl int, err error) {
+	f := strings.Fields(l)
+	if len(f) < 4 || f[3] != "@@" || !strings.HasPrefix(f[1], "-") || !strings.HasPrefix(f[2], "+") {
+		return 0, 0, 0, fmt.Errorf("Malformed hunk header: %q", l)
+	}
+	parse := func(s string) (start, lines int, err error) {
+		lines = 1
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			_, err = fmt.Sscanf(s, "%d,%d", &start, &lines)
+		} else {
+			_, err = fmt.Sscanf(s, "%d", &start)
+		}
+		return
+	}
+	if start, ol, err = parse(f[1][1:]); err == nil {
+		_, nl, err = parse(f[2][1:])
+	}
+	if err != nil {
+		err = fmt.Errorf("Malformed hunk header: %q", l)
+	}
+	return
+}
+
+// Returns the index of the line closest to pos, but not before min,
+// where the buffer lines match the given lines, or -1 if there is none
+func findLines(lines, match []string, pos, min int) int {
+	matches := func(p int) bool {
+		for i := range match {
+			if lines[p+i] != match[i] {
+				return false
+			}
+		}
+		return true
+	}
+	max := len(lines) - len(match)
+	for d := 0; pos-d >= min || pos+d <= max; d++ {
+		if p := pos - d; p >= min && p <= max && matches(p) {
+			return p
+		}
+		if p := pos + d; d > 0 && p >= min && p <= max && matches(p) {
+			return p
+		}
+	}
+	return -1
+}
+
+// ApplyPatch applies the hunks of the unified diff patch to the
+// buffer, returning the applied action which undoes all of them.
+//
+// A hunk that doesn't apply where its header says is looked for
+// elsewhere in the buffer, nearest first, and later hunks are
+// expected to be offset by as much. If it still doesn't apply, up
+// to fuzz of the context lines at its start and end are ignored.
+// The hunks that don't apply even so are skipped, and returned as
+// the regions of the patch text they occupy.
+//
+// The file headers of the patch are ignored, so it should only
+// contain changes to one file.
+func ApplyPatch(b Buffer, patch string, fuzz int) (*CompositeAction, []Region, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return nil, nil, err
+	}
+	regions := fullLines(b)
+	lines := make([]string, len(regions))
+	for i, r := range regions {
+		lines[i] = b.Substr(r)
+	}
+	start := func(line int) int {
+		if line < len(regions) {
+			return regions[line].A
+		}
+		return b.Size()
+	}
+
+	var (
+		rejected []Region
+		diff     []Hunk
+		text     []rune
+		offset   int
+		// Hunks may not apply to lines earlier hunks changed
+		min int
+	)
+	for _, h := range hunks {
+		p, l := -1, 0
+		var old, new []string
+		for f := 0; f <= fuzz && p < 0; f++ {
+			t := Min(f, h.trailing)
+			l = Min(f, h.leading)
+			if f > 0 && l+t == 0 {
+				break
+			}
+			old, new = h.old[l:len(h.old)-t], h.new[l:len(h.new)-t]
+			p = findLines(lines, old, h.pos+l+offset, min)
+		}
+		if p < 0 {
+			rejected = append(rejected, h.region)
+			continue
+		}
+		offset = p - h.pos - l
+		min = p + len(old)
+
+		replacement := []rune(strings.Join(new, ""))
+		diff = append(diff, Hunk{
+			Region{start(p), start(p + len(old))},
+			Region{len(text), len(text) + len(replacement)},
+		})
+		text = append(text, replacement...)
+	}
+	ca := DiffAction(b, text, diff)
+	ca.Apply()
+	return ca, rejected, nil
+}
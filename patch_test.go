@@ -0,0 +1,108 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testPatch = `--- a/test.txt
++++ b/test.txt
+@@ -1,4 +1,4 @@
+ one
+-two
++2
+ three
+ four
+@@ -7,3 +7,4 @@ six
+ seven
+ eight
+ nine
++nine and a half
+`
+
+func TestApplyPatch(t *testing.T) {
+	const (
+		orig = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\n"
+		exp  = "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nnine and a half\n"
+	)
+	tests := []struct {
+		in, exp  string
+		fuzz     int
+		rejected []Region
+	}{
+		{orig, exp, 0, nil},
+		// Offset by a couple of lines
+		{"zero\n-\n" + orig, "zero\n-\n" + exp, 0, nil},
+		// The first line is missing, so the first hunk is rejected
+		// and the second applies a line earlier
+		{"two\nthree\nfour\nfive\nsix\nseven\neight\nnine\n", "two\nthree\nfour\nfive\nsix\nseven\neight\nnine\nnine and a half\n", 0, []Region{{30, 72}}},
+		// The first context line differs, so fuzz is needed
+		{"ONE\ntwo\nthree\nfour\n", "ONE\ntwo\nthree\nfour\n", 0, []Region{{30, 72}, {72, 129}}},
+		{"ONE\ntwo\nthree\nfour\n", "ONE\n2\nthree\nfour\n", 1, []Region{{72, 129}}},
+		{"", "", 2, []Region{{30, 72}, {72, 129}}},
+	}
+	for i, test := range tests {
+		b := NewBuffer()
+		b.Insert(0, test.in)
+		ca, rejected, err := ApplyPatch(b, testPatch, test.fuzz)
+		if err != nil {
+			t.Errorf("Test %d: %s", i, err)
+			b.Close()
+			continue
+		}
+		if s := b.Substr(Region{0, b.Size()}); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		if !reflect.DeepEqual(rejected, test.rejected) {
+			t.Errorf("Test %d: Expected %v to be rejected, but got %v", i, test.rejected, rejected)
+		}
+		ca.Undo()
+		if s := b.Substr(Region{0, b.Size()}); s != test.in {
+			t.Errorf("Test %d: Expected %q after undoing, but got %q", i, test.in, s)
+		}
+		b.Close()
+	}
+}
+
+func TestApplyPatchEdges(t *testing.T) {
+	tests := []struct {
+		in, patch, exp string
+	}{
+		// Inserting into an empty buffer
+		{"", "@@ -0,0 +1,2 @@\n+a\n+b\n", "a\nb\n"},
+		// Inserting after a line
+		{"a\nc\n", "@@ -1,0 +2 @@\n+b\n", "a\nb\nc\n"},
+		// Missing newlines at the end of the file
+		{"a\nb", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n", "a\nb\n"},
+		{"a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n\\ No newline at end of file\n", "a\nc"},
+		// Stripped empty context lines and CRLF line endings
+		{"a\n\nb\n", "@@ -1,3 +1,3 @@\r\n a\r\n\r\n-b\r\n+c\r\n", "a\n\nc\n"},
+	}
+	for i, test := range tests {
+		b := NewBuffer()
+		b.Insert(0, test.in)
+		if _, rejected, err := ApplyPatch(b, test.patch, 0); err != nil || rejected != nil {
+			t.Errorf("Test %d: Unexpected %v, %v", i, rejected, err)
+		} else if s := b.Substr(Region{0, b.Size()}); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		b.Close()
+	}
+
+	b := NewBuffer()
+	defer b.Close()
+	for _, patch := range []string{
+		"@@ -1,2 @@\n",
+		"@@ -x +1 @@\n",
+		"@@ -1,2 +1,2 @@\n a\n",
+		"@@ -1 +1 @@\n*a\n",
+	} {
+		if _, _, err := ApplyPatch(b, patch, 0); err == nil {
+			t.Errorf("Expected an error applying %q", patch)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"unicode/utf8"
+)
+
+// Number of runes between each position recorded in the
+// index of the piece table's original data
+const piece_block = 1024
+
+type (
+	// Piece table (http://en.wikipedia.org/wiki/Piece_table)
+	//
+	// The original contents are a read-only UTF-8 byte slice, possibly
+	// backed by a memory mapped file, and all inserted data is appended
+	// to a separate rune slice. The buffer's contents are described by a
+	// list of pieces, each referencing a range in one of the two.
+	//
+	// Opening a file thus only requires a single pass over it to build
+	// an index, rather than decoding all of it into runes.
+	pieceTable struct {
+		orig   *pieceSource
+		add    []rune
+		pieces []piece
+		size   int
+		close  func() error
+		// The file the original data is mapped from, if any
+		file os.FileInfo
+	}
+
+	// Implemented by InnerBufferInterface implementations that
+	// might read from the file they were opened from while open
+	fileReader interface {
+		// Stops reading from the file at path, if that is the file
+		// being read from, so that it can be written to in place
+		release(path string) error
+	}
+
+	piece struct {
+		add bool
+		// Rune offset into the source and length in runes
+		start, length int
+		// Number of newlines in the piece
+		lines int
+	}
+
+	// Index over UTF-8 data allowing rune offsets to be turned
+	// into byte offsets without decoding everything before it.
+	// Bytes that aren't valid UTF-8 are runes of their own, escaped
+	// as by decode so that they survive being saved.
+	pieceSource struct {
+		data []byte
+		// Byte offset of every piece_block'th rune
+		marks []int
+		// Number of newlines before every piece_block'th rune
+		lines []int
+		runes int
+	}
+)
+
+// Returns a new InnerBufferInterface implementation storing its data
+// in a piece table, whose original contents are the given UTF-8 data.
+// The data is not copied and must not be modified afterwards.
+func NewPieceTableBackend(original []byte) InnerBufferInterface {
+	return newPieceTable(original, nil)
+}
+
+// Returns a new InnerBufferInterface implementation storing its data
+// in a piece table, whose original contents are those of the given file.
+// If useMmap is true and the platform supports it, the file is memory
+// mapped rather than read into memory, and stays mapped until the
+// backend is closed. The file must then not be modified by anything
+// else while the backend is open. Saving a buffer to it in place
+// copies the original contents into memory first.
+func OpenPieceTableBackend(path string, useMmap bool) (InnerBufferInterface, error) {
+	if useMmap {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		data, unmap, err := mmapFile(path)
+		if err != nil {
+			return nil, err
+		}
+		pt := newPieceTable(data, unmap)
+		pt.file = fi
+		return pt, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newPieceTable(data, nil), nil
+}
+
+func newPieceTable(data []byte, close func() error) *pieceTable {
+	pt := &pieceTable{orig: newPieceSource(data), close: close}
+	if pt.size = pt.orig.runes; pt.size > 0 {
+		pt.pieces = []piece{{false, 0, pt.size, pt.orig.linesIn(0, pt.size)}}
+	}
+	return pt
+}
+
+func newPieceSource(data []byte) *pieceSource {
+	s := &pieceSource{data: data}
+	lines := 0
+	for i := 0; i < len(data); s.runes++ {
+		if s.runes%piece_block == 0 {
+			s.marks = append(s.marks, i)
+			s.lines = append(s.lines, lines)
+		}
+		if c := data[i]; c < utf8.RuneSelf {
+			if c == '\n' {
+				lines++
+			}
+			i++
+		} else {
+			_, n := utf8.DecodeRune(data[i:])
+			i += n
+		}
+	}
+	s.marks = append(s.marks, len(data))
+	s.lines = append(s.lines, lines)
+	return s
+}
+
+// Returns the byte offset of the given rune offset
+func (s *pieceSource) offset(pos int) int {
+	b := pos / piece_block
+	i := s.marks[b]
+	for n := pos - b*piece_block; n > 0; n-- {
+		_, l := utf8.DecodeRune(s.data[i:])
+		i += l
+	}
+	return i
+}
+
+func (s *pieceSource) appendRunes(data []rune, a, e int) []rune {
+	for i := s.offset(a); a < e; a++ {
+		r, l := decodeEscaped(s.data[i:])
+		data = append(data, r)
+		i += l
+	}
+	return data
+}
+
+func (s *pieceSource) index(pos int) rune {
+	r, _ := decodeEscaped(s.data[s.offset(pos):])
+	return r
+}
+
+// Returns the number of newlines before the given rune offset
+func (s *pieceSource) linesBefore(pos int) int {
+	b := pos / piece_block
+	ret := s.lines[b]
+	for i, n := s.marks[b], pos-b*piece_block; n > 0; n-- {
+		if s.data[i] == '\n' {
+			ret++
+		}
+		_, l := utf8.DecodeRune(s.data[i:])
+		i += l
+	}
+	return ret
+}
+
+func (s *pieceSource) linesIn(a, e int) int {
+	return s.linesBefore(e) - s.linesBefore(a)
+}
+
+// Returns the rune offset right after the n'th newline
+// following the rune offset pos
+func (s *pieceSource) afterNewline(pos, n int) int {
+	target := s.linesBefore(pos) + n
+	// The last block with fewer newlines before it than the target
+	b := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] >= target }) - 1
+	if p := b * piece_block; p > pos {
+		pos, n = p, target-s.lines[b]
+	}
+	for i := s.offset(pos); ; pos++ {
+		r, l := utf8.DecodeRune(s.data[i:])
+		i += l
+		if r == '\n' {
+			if n--; n == 0 {
+				return pos + 1
+			}
+		}
+	}
+}
+
+func (pt *pieceTable) Close() {
+	if pt.close != nil {
+		pt.close()
+		pt.close = nil
+	}
+}
+
+func (pt *pieceTable) release(path string) error {
+	if pt.file == nil {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !os.SameFile(fi, pt.file) {
+		return nil
+	}
+	// The pieces are rune offsets, so the index
+	// is still valid for the copy
+	pt.orig.data = append([]byte(nil), pt.orig.data...)
+	pt.file = nil
+	pt.Close()
+	return nil
+}
+
+func (pt *pieceTable) Size() int {
+	return pt.size
+}
+
+func (pt *pieceTable) appendPiece(data []rune, p piece, a, e int) []rune {
+	if p.add {
+		return append(data, pt.add[p.start+a:p.start+e]...)
+	}
+	return pt.orig.appendRunes(data, p.start+a, p.start+e)
+}
+
+func (pt *pieceTable) linesIn(p piece, a, e int) int {
+	if p.add {
+		return linecount(pt.add[p.start+a : p.start+e])
+	}
+	return pt.orig.linesIn(p.start+a, p.start+e)
+}
+
+// Returns the index of the piece containing the given position and
+// the offset into it. Positions at the boundary between two pieces
+// are considered to be at the end of the first one.
+func (pt *pieceTable) find(pos int) (int, int) {
+	for i, p := range pt.pieces {
+		if pos <= p.length {
+			return i, pos
+		}
+		pos -= p.length
+	}
+	return len(pt.pieces), 0
+}
+
+// Makes sure there is a piece boundary at the given position,
+// returning the index of the first piece after it
+func (pt *pieceTable) split(pos int) int {
+	i, off := pt.find(pos)
+	if i == len(pt.pieces) {
+		return i
+	}
+	p := pt.pieces[i]
+	if off == p.length {
+		return i + 1
+	} else if off == 0 {
+		return i
+	}
+	left := piece{p.add, p.start, off, pt.linesIn(p, 0, off)}
+	right := piece{p.add, p.start + off, p.length - off, p.lines - left.lines}
+	pt.pieces = append(pt.pieces, piece{})
+	copy(pt.pieces[i+2:], pt.pieces[i+1:])
+	pt.pieces[i], pt.pieces[i+1] = left, right
+	return i + 1
+}
+
+func (pt *pieceTable) SubstrR(r Region) []rune {
+	a, e := Clamp(0, pt.size, r.Begin()), Clamp(0, pt.size, r.End())
+	data := make([]rune, 0, e-a)
+	for _, p := range pt.pieces {
+		if a >= e {
+			break
+		}
+		if a < p.length {
+			data = pt.appendPiece(data, p, a, Min(e, p.length))
+		}
+		a, e = Max(0, a-p.length), e-p.length
+	}
+	return data
+}
+
+func (pt *pieceTable) Index(pos int) rune {
+	if pos < 0 || pos >= pt.size {
+		panic(fmt.Sprintf("Index out of bounds: %d >= %d", pos, pt.size))
+	}
+	i, off := pt.find(pos + 1)
+	p := pt.pieces[i]
+	if p.add {
+		return pt.add[p.start+off-1]
+	}
+	return pt.orig.index(p.start + off - 1)
+}
+
+func (pt *pieceTable) InsertR(point int, value []rune) error {
+	if len(value) == 0 {
+		return nil
+	}
+	point = Clamp(0, pt.size, point)
+	i, off := pt.find(point)
+	lines := linecount(value)
+	if i < len(pt.pieces) {
+		// Typing at the end of the last inserted data
+		// just makes that piece longer
+		if p := &pt.pieces[i]; p.add && off == p.length && p.start+p.length == len(pt.add) {
+			pt.add = append(pt.add, value...)
+			p.length += len(value)
+			p.lines += lines
+			pt.size += len(value)
+			return nil
+		}
+	}
+	i = pt.split(point)
+	pt.pieces = append(pt.pieces, piece{})
+	copy(pt.pieces[i+1:], pt.pieces[i:])
+	pt.pieces[i] = piece{true, len(pt.add), len(value), lines}
+	pt.add = append(pt.add, value...)
+	pt.size += len(value)
+	return nil
+}
+
+func (pt *pieceTable) Erase(point, length int) error {
+	point = Clamp(0, pt.size, point)
+	length = Clamp(0, pt.size-point, length)
+	if length == 0 {
+		return nil
+	}
+	i := pt.split(point)
+	j := pt.split(point + length)
+	pt.pieces = append(pt.pieces[:i], pt.pieces[j:]...)
+	pt.size -= length
+	return nil
+}
+
+// Returns the position right after the row'th newline,
+// or the size of the buffer if there aren't that many
+func (pt *pieceTable) lineStart(row int) int {
+	pos := 0
+	for _, p := range pt.pieces {
+		if row > p.lines {
+			row -= p.lines
+			pos += p.length
+			continue
+		}
+		if p.add {
+			for o := p.start; ; o++ {
+				if pt.add[o] == '\n' {
+					if row--; row == 0 {
+						return pos + o - p.start + 1
+					}
+				}
+			}
+		}
+		return pos + pt.orig.afterNewline(p.start, row) - p.start
+	}
+	return pt.size
+}
+
+func (pt *pieceTable) RowCol(point int) (row, col int) {
+	point = Clamp(0, pt.size, point)
+	pos := 0
+	for _, p := range pt.pieces {
+		if point-pos <= p.length {
+			row += pt.linesIn(p, 0, point-pos)
+			break
+		}
+		row += p.lines
+		pos += p.length
+	}
+	if row == 0 {
+		return 0, point
+	}
+	return row, point - pt.lineStart(row)
+}
+
+func (pt *pieceTable) TextPoint(row, col int) (i int) {
+	if row == 0 && col == 0 {
+		return 0
+	}
+	if row > 0 {
+		i = pt.lineStart(row)
+	}
+	if i < pt.size {
+		return i + col
+	}
+	return i
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPieceTableOriginal(t *testing.T) {
+	const path = "./testdata/unittest.cpp"
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, useMmap := range []bool{false, true} {
+		bi, err := OpenPieceTableBackend(path, useMmap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []rune(string(data))
+		checkBackend(t, -1, bi, exp)
+		for i := 0; i < 200; i++ {
+			p := rand.Intn(len(exp) + 1)
+			if len(exp) > 0 && i%2 == 0 {
+				l := rand.Intn(Min(len(exp)-Min(p, len(exp)-1), 64))
+				bi.Erase(p, l)
+				exp = append(exp[:p:p], exp[Min(p+l, len(exp)):]...)
+			} else {
+				in := make([]rune, 1+rand.Intn(8))
+				fill(in)
+				bi.InsertR(p, in)
+				exp = append(exp[:p:p], append(in, exp[p:]...)...)
+			}
+			checkBackend(t, i, bi, exp)
+		}
+		bi.Close()
+	}
+
+	if _, err := OpenPieceTableBackend("./testdata/nonexistent", true); err == nil {
+		t.Error("Expected an error opening a nonexistent file")
+	}
+}
+
+func TestPieceTableUtf(t *testing.T) {
+	const init = "€þıœəßðĸʒ×ŋµåäö𝄞\nabc\n"
+	var data string
+	for i := 0; i < 200; i++ {
+		data += init
+	}
+	bi := NewPieceTableBackend([]byte(data))
+	exp := []rune(data)
+	checkBackend(t, -1, bi, exp)
+	for _, test := range []struct{ row, col, exp int }{
+		{0, 3, 3},
+		{1, 2, 19},
+		{150, 1, 75*21 + 1},
+		{401, 0, len(exp)},
+	} {
+		if p := bi.TextPoint(test.row, test.col); p != test.exp {
+			t.Errorf("Expected %d for %d, %d, but got %d", test.exp, test.row, test.col, p)
+		}
+	}
+}
+
+func TestPieceTableSaveInPlace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+	text := strings.Repeat("hello world\n", 1000)
+	if err := ioutil.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bi, err := OpenPieceTableBackend(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBufferWith(bi)
+	defer b.Close()
+	b.Settings().Set("atomic_save", false)
+
+	// Both making the file longer, which moves the original text in
+	// it, and shorter, which leaves some of it past the end
+	b.Insert(0, "x")
+	exp := "x" + text
+	for i := 0; i < 2; i++ {
+		if err := b.SaveFile(path); err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if s := b.Substr(Region{0, b.Size()}); s != exp {
+			t.Errorf("Test %d: Expected the buffer to be unchanged by saving", i)
+		}
+		if data, _ := ioutil.ReadFile(path); string(data) != exp {
+			t.Errorf("Test %d: Unexpected file contents", i)
+		}
+		b.Erase(101, b.Size()-101)
+		exp = exp[:101]
+	}
+}
+
+func TestPieceTableInvalidUTF8(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+	const data = "a\xffb\xe2\x82\nc\xc3"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bi, err := OpenPieceTableBackend(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBufferWith(bi)
+	defer b.Close()
+	exp := decode([]byte(data), UTF8)
+	checkBackend(t, -1, bi, exp)
+	if r := bi.Index(1); r != 0xdcff {
+		t.Errorf("Expected %U, but got %U", 0xdcff, r)
+	}
+
+	out := filepath.Join(dir, "out.txt")
+	if err := b.SaveFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := ioutil.ReadFile(out); string(d) != data {
+		t.Errorf("Expected %q, but got %q", data, d)
+	}
+}
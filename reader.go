@@ -0,0 +1,175 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+type (
+	// Reader reads the text of a region of a buffer as UTF-8. It
+	// implements io.Reader, io.RuneScanner, io.Seeker and io.WriterTo.
+	//
+	// The text is read from a snapshot of the buffer taken when the
+	// Reader was created, straight from the buffer's storage, so later
+	// modifications to the buffer don't affect it. Runes that aren't
+	// valid Unicode are read as utf8.RuneError, as with ByteOffset.
+	Reader struct {
+		inner InnerBufferInterface
+		rr    runeReader
+		// The UTF-8 offsets of the start and end of the region
+		base, size int64
+		// The rest of a rune that has only been partly read
+		pending []byte
+		buf     [utf8.UTFMax]byte
+		// Whether the last thing done was reading a whole rune
+		// with ReadRune, which can then be unread
+		canUnread bool
+	}
+)
+
+var (
+	ErrInvalidUnreadRune = fmt.Errorf("Previous operation was not a successful ReadRune")
+	ErrInvalidWhence     = fmt.Errorf("Invalid whence")
+	ErrNegativePosition  = fmt.Errorf("Negative position")
+)
+
+// NewReader returns a Reader reading the text of the given region of
+// the buffer.
+func NewReader(buf Buffer, r Region) *Reader {
+	var inner InnerBufferInterface
+	if s, ok := buf.Snapshot().(*snapshot); ok {
+		inner = s.inner
+	} else {
+		// Not one of our buffers, so it has to be copied
+		inner = newNode(buf.SubstrR(Region{0, buf.Size()}))
+	}
+	s := inner.Size()
+	r = Region{Clamp(0, s, r.Begin()), Clamp(0, s, r.End())}
+	begin, _ := unitsOf(inner, r.A)
+	end, _ := unitsOf(inner, r.B)
+	return &Reader{
+		inner: inner,
+		rr:    runeReader{inner: inner, pos: r.A, end: r.B},
+		base:  int64(begin),
+		size:  int64(end - begin),
+	}
+}
+
+// Makes the next rune read be the one at the text position pos
+func (r *runeReader) seek(pos int) {
+	r.pos = pos
+	r.chunk = nil
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	r.canUnread = false
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			ru, _, err := r.rr.ReadRune()
+			if err != nil {
+				break
+			}
+			l := utf8.EncodeRune(r.buf[:], ru)
+			r.pending = r.buf[:l]
+		}
+		c := copy(p[n:], r.pending)
+		r.pending = r.pending[c:]
+		n += c
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (r *Reader) ReadRune() (ru rune, size int, err error) {
+	r.canUnread = false
+	if len(r.pending) > 0 {
+		// In the middle of a rune, so what is left of it
+		// is read a byte at a time, like invalid UTF-8
+		r.pending = r.pending[1:]
+		return utf8.RuneError, 1, nil
+	}
+	if ru, _, err = r.rr.ReadRune(); err != nil {
+		return 0, 0, err
+	}
+	if !utf8.ValidRune(ru) {
+		ru = utf8.RuneError
+	}
+	r.canUnread = true
+	return ru, utf8.RuneLen(ru), nil
+}
+
+func (r *Reader) UnreadRune() error {
+	if !r.canUnread {
+		return ErrInvalidUnreadRune
+	}
+	r.canUnread = false
+	r.rr.seek(r.rr.pos - 1)
+	return nil
+}
+
+// Seek sets the UTF-8 offset, from the start of the region, of the
+// next byte read. Seeking past the end of the region ends up at the
+// end of it, and seeking into the middle of a rune reads the rest
+// of it.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	r.canUnread = false
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset()
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, ErrInvalidWhence
+	}
+	if offset < 0 {
+		return 0, ErrNegativePosition
+	}
+	if offset >= r.size {
+		r.rr.seek(r.rr.end)
+		r.pending = nil
+		return r.size, nil
+	}
+
+	p := pointFrom(r.inner, int(r.base+offset), false)
+	start, _ := unitsOf(r.inner, p)
+	r.rr.seek(p)
+	r.pending = nil
+	if skip := int(r.base+offset) - start; skip > 0 {
+		// Somewhere in the middle of the rune at p
+		ru, _, _ := r.rr.ReadRune()
+		l := utf8.EncodeRune(r.buf[:], ru)
+		r.pending = r.buf[skip:l]
+	}
+	return offset, nil
+}
+
+// Returns the current UTF-8 offset from the start of the region
+func (r *Reader) offset() int64 {
+	b, _ := unitsOf(r.inner, r.rr.pos)
+	return int64(b) - r.base - int64(len(r.pending))
+}
+
+func (r *Reader) WriteTo(w io.Writer) (n int64, err error) {
+	buf := make([]byte, 32*1024)
+	for {
+		c, err := r.Read(buf)
+		if c > 0 {
+			c, err := w.Write(buf[:c])
+			n += int64(c)
+			if err != nil {
+				return n, err
+			}
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestReader(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	text := strings.Repeat("abc åäö 日本 😀\n", 500)
+	b.Insert(0, text)
+
+	for _, r := range []Region{{0, b.Size()}, {0, 0}, {4, 9}, {9, 4}, {100, 5000}, {-5, 1 << 20}} {
+		exp := b.Substr(r)
+		if r.A < 0 {
+			exp = text
+		}
+		data, err := ioutil.ReadAll(NewReader(b, r))
+		if err != nil {
+			t.Error(err)
+		} else if string(data) != exp {
+			t.Errorf("Unexpected text read from %v", r)
+		}
+
+		var buf bytes.Buffer
+		if n, err := NewReader(b, r).WriteTo(&buf); err != nil || n != int64(len(exp)) || buf.String() != exp {
+			t.Errorf("Unexpected WriteTo of %v: %d, %v", r, n, err)
+		}
+
+		rr := NewReader(b, r)
+		var got []rune
+		for {
+			ru, size, err := rr.ReadRune()
+			if err == io.EOF {
+				break
+			}
+			if size != utf8.RuneLen(ru) {
+				t.Errorf("Expected %q to have size %d, but got %d", ru, utf8.RuneLen(ru), size)
+			}
+			got = append(got, ru)
+		}
+		if string(got) != exp {
+			t.Errorf("Unexpected runes read from %v", r)
+		}
+	}
+
+	// Later changes to the buffer don't affect the reader
+	r := NewReader(b, Region{0, 3})
+	b.Erase(0, 3)
+	if data, _ := ioutil.ReadAll(r); string(data) != "abc" {
+		t.Errorf("Expected %q, but got %q", "abc", data)
+	}
+}
+
+func TestReaderRuneScanner(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "aå日")
+	r := NewReader(b, Region{0, b.Size()})
+
+	if err := r.UnreadRune(); err != ErrInvalidUnreadRune {
+		t.Errorf("Expected ErrInvalidUnreadRune, but got %v", err)
+	}
+	r.ReadRune()
+	if ru, _, _ := r.ReadRune(); ru != 'å' {
+		t.Errorf("Expected å, but got %q", ru)
+	}
+	if err := r.UnreadRune(); err != nil {
+		t.Error(err)
+	}
+	if err := r.UnreadRune(); err != ErrInvalidUnreadRune {
+		t.Errorf("Expected ErrInvalidUnreadRune, but got %v", err)
+	}
+	if ru, _, _ := r.ReadRune(); ru != 'å' {
+		t.Errorf("Expected å again, but got %q", ru)
+	}
+
+	// Reading part of a rune leaves the rest of it
+	var p [1]byte
+	r.Read(p[:])
+	if ru, size, _ := r.ReadRune(); ru != utf8.RuneError || size != 1 {
+		t.Errorf("Expected the middle of a rune to be an error, but got %q, %d", ru, size)
+	}
+	if r.UnreadRune() == nil {
+		t.Error("Didn't expect to be able to unread part of a rune")
+	}
+}
+
+func TestReaderSeek(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "xxaå日bxx")
+	r := NewReader(b, Region{2, 6})
+
+	tests := []struct {
+		offset int64
+		whence int
+		pos    int64
+		rest   string
+	}{
+		{0, io.SeekStart, 0, "aå日b"},
+		{1, io.SeekStart, 1, "å日b"},
+		{2, io.SeekStart, 2, "\xa5日b"},
+		{-1, io.SeekEnd, 6, "b"},
+		{-3, io.SeekEnd, 4, "\x97\xa5b"},
+		{100, io.SeekStart, 7, ""},
+		{0, io.SeekEnd, 7, ""},
+	}
+	for i, test := range tests {
+		pos, err := r.Seek(test.offset, test.whence)
+		if err != nil || pos != test.pos {
+			t.Errorf("Test %d: Expected %d, but got %d, %v", i, test.pos, pos, err)
+		}
+		if cur, _ := r.Seek(0, io.SeekCurrent); cur != test.pos {
+			t.Errorf("Test %d: Expected the current position to be %d, but got %d", i, test.pos, cur)
+		}
+		if data, _ := ioutil.ReadAll(r); string(data) != test.rest {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.rest, data)
+		}
+	}
+
+	r.Seek(1, io.SeekStart)
+	r.ReadRune()
+	if pos, _ := r.Seek(-1, io.SeekCurrent); pos != 2 {
+		t.Errorf("Expected 2, but got %d", pos)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err != ErrNegativePosition {
+		t.Errorf("Expected ErrNegativePosition, but got %v", err)
+	}
+	if _, err := r.Seek(0, 42); err != ErrInvalidWhence {
+		t.Errorf("Expected ErrInvalidWhence, but got %v", err)
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.InsertR(0, []rune{'a', 0xdc80, 'b'})
+	data, _ := ioutil.ReadAll(NewReader(b, Region{0, 3}))
+	if exp := "a�b"; string(data) != exp {
+		t.Errorf("Expected %q, but got %q", exp, data)
+	}
+	if len(data) != b.ByteOffset(3) {
+		t.Errorf("Expected the length to match ByteOffset, but got %d", len(data))
+	}
+}
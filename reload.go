@@ -0,0 +1,38 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+)
+
+func (b *buffer) Reload() (*CompositeAction, error) {
+	path := b.FileName()
+	if path == "" {
+		return nil, ErrNoFileName
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, enc, bom, odd := decodeFile(data)
+	text, le, other := normalizeLineEndings(text)
+
+	// Diffing line by line first, as that is much cheaper
+	// than diffing the whole text rune by rune
+	hunks := refine(b.SubstrR(Region{0, b.Size()}), text, diffText(b, text))
+	ca := DiffAction(b, text, hunks)
+	ca.Apply()
+
+	b.lock.Lock()
+	b.encoding = enc
+	b.bom = bom
+	b.odd = odd
+	b.lineEnding = le
+	b.endings = other
+	b.lock.Unlock()
+	b.recordDiskState(path, data)
+	return ca, nil
+}
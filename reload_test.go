@@ -0,0 +1,88 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+	ioutil.WriteFile(path, []byte("first line\nsecond line\nthird line\n"), 0644)
+
+	b := NewBuffer()
+	defer b.Close()
+	if _, err := b.Reload(); err != ErrNoFileName {
+		t.Errorf("Expected ErrNoFileName, but got %v", err)
+	}
+	if err := b.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := b.CheckExternalChange(); c || err != nil {
+		t.Errorf("Expected no external change, but got %v, %v", c, err)
+	}
+
+	// A region on the third line, which should keep
+	// pointing at it after reloading
+	var rs RegionSet
+	rs.Add(Region{23, 28})
+	b.AddCallback(func(_ Buffer, position, delta int) {
+		rs.Adjust(position, delta)
+	})
+	var changes []Region
+	b.AddCallback(func(_ Buffer, position, delta int) {
+		changes = append(changes, Region{position, position + delta})
+	})
+
+	ioutil.WriteFile(path, []byte("first line\r\nsecond changed line\r\nthird line\r\n"), 0644)
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if c, err := b.CheckExternalChange(); !c || err != nil {
+		t.Errorf("Expected an external change, but got %v, %v", c, err)
+	}
+
+	ca, err := b.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "first line\nsecond changed line\nthird line\n"
+	if s := b.Substr(Region{0, b.Size()}); s != exp {
+		t.Errorf("Expected %q, but got %q", exp, s)
+	}
+	if len(changes) != 1 || changes[0] != (Region{18, 26}) {
+		t.Errorf("Expected a single insertion, but got %v", changes)
+	}
+	if r := rs.Get(0); b.Substr(r) != "third" {
+		t.Errorf("Expected the region to still cover %q, but it covers %q", "third", b.Substr(r))
+	}
+	if le := b.LineEnding(); le != CRLF {
+		t.Errorf("Expected the line ending to be updated, but got %s", le)
+	}
+	if c, err := b.CheckExternalChange(); c || err != nil {
+		t.Errorf("Expected no external change after reloading, but got %v, %v", c, err)
+	}
+
+	ca.Undo()
+	if s := b.Substr(Region{0, b.Size()}); s != "first line\nsecond line\nthird line\n" {
+		t.Errorf("Expected the reload to be undone, but got %q", s)
+	}
+
+	os.Remove(path)
+	if c, err := b.CheckExternalChange(); !c || err != nil {
+		t.Errorf("Expected removing the file to be a change, but got %v, %v", c, err)
+	}
+	if _, err := b.Reload(); err == nil {
+		t.Error("Expected an error reloading a removed file")
+	}
+}
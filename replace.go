@@ -0,0 +1,107 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Returns the template expanded with the submatches of the match m,
+// which are text positions in the inner buffer
+func (f *finder) expand(inner InnerBufferInterface, template string, m []int) string {
+	src := inner.SubstrR(Region{m[0], m[1]})
+	// regexp wants byte offsets into the matched text
+	offsets := make([]int, len(src)+1)
+	for i, r := range src {
+		offsets[i+1] = offsets[i] + len(string(r))
+	}
+	bm := make([]int, len(m))
+	for i, o := range m {
+		bm[i] = -1
+		if o >= 0 {
+			bm[i] = offsets[o-m[0]]
+		}
+	}
+	return string(f.re.Expand(nil, []byte(template), []byte(string(src)), bm))
+}
+
+// Returns repl changed to the case of s, if s is all upper
+// case, all lower case or starts with a capital letter
+func preserveCase(s, repl string) string {
+	var letters, upper, lower int
+	firstUpper := false
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.IsUpper(r) {
+			firstUpper = firstUpper || letters == 0
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+		letters++
+	}
+	switch {
+	case letters == 0:
+		return repl
+	case upper == letters && letters > 1:
+		return strings.ToUpper(repl)
+	case lower == letters:
+		return strings.ToLower(repl)
+	case firstUpper && lower == letters-1:
+		r, n := utf8.DecodeRuneInString(repl)
+		return string(unicode.ToUpper(r)) + repl[n:]
+	}
+	return repl
+}
+
+func (b *buffer) ReplaceAll(pattern, template string, scope *RegionSet, flags FindFlags) (*CompositeAction, error) {
+	f, err := newFinder(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	inner := b.SerializedBuffer.snapshot()
+	regions := []Region{{0, inner.Size()}}
+	if scope != nil {
+		regions = scope.Regions()
+		sort.Slice(regions, func(i, j int) bool {
+			return regions[i].Begin() < regions[j].Begin()
+		})
+	}
+
+	type replacement struct {
+		region Region
+		value  string
+	}
+	var reps []replacement
+	for _, r := range regions {
+		if scope != nil && r.Empty() {
+			continue
+		}
+		r = Region{Clamp(0, inner.Size(), r.Begin()), Clamp(0, inner.Size(), r.End())}
+		for _, m := range f.matchAll(inner, r, flags&Literal == 0) {
+			value := template
+			if flags&Literal == 0 {
+				value = f.expand(inner, template, m)
+			}
+			if flags&PreserveCase != 0 {
+				value = preserveCase(string(inner.SubstrR(Region{m[0], m[1]})), value)
+			}
+			reps = append(reps, replacement{Region{m[0], m[1]}, value})
+		}
+	}
+
+	ca := &CompositeAction{}
+	// Going backwards so that the regions of the
+	// remaining replacements are unaffected
+	for i := len(reps) - 1; i >= 0; i-- {
+		ca.AddExec(NewReplaceAction(b, reps[i].region, reps[i].value))
+	}
+	return ca, nil
+}
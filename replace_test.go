@@ -0,0 +1,90 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+func TestPreserveCase(t *testing.T) {
+	tests := []struct {
+		s, repl, exp string
+	}{
+		{"hello", "World", "world"},
+		{"HELLO", "World", "WORLD"},
+		{"Hello", "world", "World"},
+		{"H", "world", "World"},
+		{"hELLO", "World", "World"},
+		{"123", "World", "World"},
+		{"Äpple", "örn", "Örn"},
+	}
+	for i, test := range tests {
+		if s := preserveCase(test.s, test.repl); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	const text = "foo(a, b)\nFoo(c)\nFOO(d, e)\nbar(f)\n"
+	tests := []struct {
+		pattern, template string
+		scope             []Region
+		flags             FindFlags
+		exp               string
+	}{
+		{"foo", "baz", nil, 0, "baz(a, b)\nFoo(c)\nFOO(d, e)\nbar(f)\n"},
+		{"foo", "baz", nil, IgnoreCase, "baz(a, b)\nbaz(c)\nbaz(d, e)\nbar(f)\n"},
+		{"foo", "baz", nil, IgnoreCase | PreserveCase, "baz(a, b)\nBaz(c)\nBAZ(d, e)\nbar(f)\n"},
+		{`(\w+)\((\w), (\w)\)`, "$1($3, $2)", nil, 0, "foo(b, a)\nFoo(c)\nFOO(e, d)\nbar(f)\n"},
+		{`(?P<f>\w+)\((?P<a>\w)\)`, "${a}.${f}()", nil, 0, "foo(a, b)\nc.Foo()\nFOO(d, e)\nf.bar()\n"},
+		{"(a, b)", "$1", nil, Literal, "foo$1\nFoo(c)\nFOO(d, e)\nbar(f)\n"},
+		{`\(`, "[", []Region{{20, 36}, {0, 4}, {8, 8}}, 0, "foo[a, b)\nFoo(c)\nFOO[d, e)\nbar[f)\n"},
+		{"^", "> ", []Region{{10, 17}}, 0, "foo(a, b)\nFoo(c)\nFOO(d, e)\nbar(f)\n"},
+		{"(?m)^", "> ", []Region{{10, 17}}, 0, "foo(a, b)\n> Foo(c)\n> FOO(d, e)\nbar(f)\n"},
+		{"x", "y", nil, 0, text},
+		{"o*", "-", []Region{{0, 4}}, 0, "-f-(-a, b)\nFoo(c)\nFOO(d, e)\nbar(f)\n"},
+	}
+	for i, test := range tests {
+		b := NewBuffer()
+		b.Insert(0, text)
+		var scope *RegionSet
+		if test.scope != nil {
+			scope = &RegionSet{}
+			for _, r := range test.scope {
+				scope.Add(r)
+			}
+		}
+
+		var edits int
+		b.AddCallback(func(Buffer, int, int) {
+			edits++
+		})
+		ca, err := b.ReplaceAll(test.pattern, test.template, scope, test.flags)
+		if err != nil {
+			t.Errorf("Test %d: %s", i, err)
+			b.Close()
+			continue
+		}
+		if s := b.Substr(Region{0, b.Size()}); s != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, s)
+		}
+		// Erasing or inserting nothing isn't an edit
+		if edits < ca.Len() || edits > 2*ca.Len() {
+			t.Errorf("Test %d: Expected an erase and an insert per replacement, but got %d edits for %d", i, edits, ca.Len())
+		}
+		ca.Undo()
+		if s := b.Substr(Region{0, b.Size()}); s != text {
+			t.Errorf("Test %d: Expected %q after undoing, but got %q", i, text, s)
+		}
+		b.Close()
+	}
+
+	b := NewBuffer()
+	defer b.Close()
+	if _, err := b.ReplaceAll("(", "", nil, 0); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// ConflictError is returned when saving a buffer to the file it
+	// was loaded from, if the file has been modified by something
+	// else since it was loaded or last saved.
+	ConflictError struct {
+		Path string
+		// Modification time of the file when it was
+		// loaded or last saved, and right now
+		Loaded, Current time.Time
+	}
+
+	// What a buffer's file looked like on disk
+	// when it was loaded or last saved
+	diskState struct {
+		path    string
+		modTime time.Time
+		size    int64
+		hash    [sha256.Size]byte
+	}
+)
+
+var ErrNoFileName = fmt.Errorf("Buffer has no file name")
+
+// Names of the settings controlling how files are saved
+const (
+	// Whether to write to a temporary file that is then renamed
+	// over the original, so that the file is never left half
+	// written. Defaults to true.
+	atomicSaveSetting = "atomic_save"
+	// Whether to make sure that the data has reached the disk
+	// before returning from a save. Defaults to true.
+	fsyncSetting = "fsync_on_save"
+	// Whether to keep a copy of the file as it was before it is
+	// overwritten: "bak" keeps it as "file.bak", "numbered" as
+	// "file.~1~", "file.~2~" and so on. Defaults to "", keeping none.
+	backupSetting = "save_backup"
+)
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s has been modified since it was loaded", e.Path)
+}
+
+func newDiskState(path string, fi os.FileInfo, data []byte) *diskState {
+	return &diskState{path, fi.ModTime(), fi.Size(), sha256.Sum256(data)}
+}
+
+// Returns whether the file at the state's path has changed since
+// the state was recorded, along with its current FileInfo, which is
+// nil if the file no longer exists
+func (s *diskState) changed() (bool, os.FileInfo, error) {
+	fi, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return true, nil, nil
+	} else if err != nil {
+		return false, nil, err
+	}
+	if fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return false, fi, nil
+	}
+	if fi.Size() != s.size {
+		return true, fi, nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return false, fi, err
+	}
+	// If the hash is the same the file was just touched
+	return sha256.Sum256(data) != s.hash, fi, nil
+}
+
+// Returns a ConflictError if the file at the state's path
+// has changed since the state was recorded
+func (s *diskState) check() error {
+	changed, fi, err := s.changed()
+	if err != nil || !changed || fi == nil {
+		// Nothing to overwrite if the file doesn't exist
+		return err
+	}
+	return &ConflictError{s.path, s.modTime, fi.ModTime()}
+}
+
+// Returns the name of the backup to make of the file at path
+func backupName(path, kind string) (string, error) {
+	switch kind {
+	case "bak":
+		return path + ".bak", nil
+	case "numbered":
+		matches, err := filepath.Glob(path + ".~*~")
+		if err != nil {
+			return "", err
+		}
+		n := 0
+		for _, m := range matches {
+			if i, err := strconv.Atoi(strings.TrimSuffix(m[len(path)+2:], "~")); err == nil && i > n {
+				n = i
+			}
+		}
+		return fmt.Sprintf("%s.~%d~", path, n+1), nil
+	}
+	return "", fmt.Errorf("Unknown backup kind: %q", kind)
+}
+
+func copyFile(dst, src string, mode os.FileMode) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, mode)
+}
+
+// Writes the data to the file at path, which keeps its mode
+// if it already exists, according to the given settings
+func writeFile(path string, data []byte, s *Settings) error {
+	atomic := s.Bool(atomicSaveSetting, true)
+	fsync := s.Bool(fsyncSetting, true)
+	backup := s.String(backupSetting, "")
+
+	// Saving through a symbolic link should replace the file it points to
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		path = p
+	}
+	mode := os.FileMode(0644)
+	fi, err := os.Stat(path)
+	if err == nil {
+		mode = fi.Mode().Perm()
+		if backup != "" {
+			name, err := backupName(path, backup)
+			if err != nil {
+				return err
+			}
+			if err := copyFile(name, path, mode); err != nil {
+				return err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if !atomic {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		return writeAndClose(f, data, fsync)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err := writeAndClose(f, data, fsync); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if fsync {
+		// Making sure the rename itself is on disk. Not all
+		// platforms support syncing directories, so errors
+		// are ignored.
+		if d, err := os.Open(filepath.Dir(path)); err == nil {
+			d.Sync()
+			d.Close()
+		}
+	}
+	return nil
+}
+
+func writeAndClose(f *os.File, data []byte, fsync bool) error {
+	_, err := f.Write(data)
+	if err == nil && fsync {
+		err = f.Sync()
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Remembers what the file at path looks like, given
+// that data was just read from or written to it
+func (b *buffer) recordDiskState(path string, data []byte) {
+	var s *diskState
+	if fi, err := os.Stat(path); err == nil {
+		s = newDiskState(path, fi, data)
+	}
+	b.lock.Lock()
+	b.disk = s
+	b.lock.Unlock()
+}
+
+func (b *buffer) saveFile(path string, force bool) error {
+	if path == "" {
+		return ErrNoFileName
+	}
+	data, err := b.encoded()
+	if err != nil {
+		return err
+	}
+	b.lock.Lock()
+	s := b.disk
+	b.lock.Unlock()
+	if !force && s != nil && s.path == path {
+		if err := s.check(); err != nil {
+			return err
+		}
+	}
+	if !b.Settings().Bool(atomicSaveSetting, true) {
+		// Overwriting the file in place would change
+		// it under a backend that is still reading it
+		if err := b.SerializedBuffer.release(path); err != nil {
+			return err
+		}
+	}
+	if err := writeFile(path, data, b.Settings()); err != nil {
+		return err
+	}
+	b.recordDiskState(path, data)
+	return b.SetFileName(path)
+}
+
+func (s *SerializedBuffer) release(path string) error {
+	s.ops <- func() interface{} {
+		if fr, ok := s.inner.(fileReader); ok {
+			return fr.release(path)
+		}
+		return nil
+	}
+	r := <-s.lockret
+	if r2, ok := r.(error); ok {
+		return r2
+	}
+	return nil
+}
+
+func (b *buffer) SaveFile(path string) error {
+	return b.saveFile(path, false)
+}
+
+func (b *buffer) ForceSaveFile(path string) error {
+	return b.saveFile(path, true)
+}
+
+func (b *buffer) Save() error {
+	return b.SaveFile(b.FileName())
+}
+
+func (b *buffer) CheckExternalChange() (bool, error) {
+	b.lock.Lock()
+	s := b.disk
+	b.lock.Unlock()
+	if s == nil || s.path != b.FileName() {
+		return false, nil
+	}
+	changed, _, err := s.changed()
+	return changed, err
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func readString(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSaveAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, atomic := range []bool{true, false} {
+		b := NewBuffer()
+		b.Settings().Set("atomic_save", atomic)
+		b.Settings().Set("fsync_on_save", atomic)
+		if err := b.LoadFile(path); err != nil {
+			t.Fatal(err)
+		}
+		b.Insert(b.Size(), "er")
+		if err := b.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if s := readString(t, path); s != "older" {
+			t.Errorf("Expected %q, but got %q", "older", s)
+		}
+		b.Close()
+		if runtime.GOOS != "windows" {
+			if fi, err := os.Stat(path); err != nil {
+				t.Error(err)
+			} else if m := fi.Mode().Perm(); m != 0640 {
+				t.Errorf("Expected the mode to be kept, but got %v", m)
+			}
+		}
+		ioutil.WriteFile(path, []byte("old"), 0640)
+	}
+
+	// No temporary files should be left behind
+	if fis, err := ioutil.ReadDir(dir); err != nil {
+		t.Error(err)
+	} else if len(fis) != 1 {
+		t.Errorf("Expected only the saved file, but found %d files", len(fis))
+	}
+
+	b := NewBuffer()
+	defer b.Close()
+	if err := b.Save(); err != ErrNoFileName {
+		t.Errorf("Expected ErrNoFileName, but got %v", err)
+	}
+}
+
+func TestSaveBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+
+	b := NewBuffer()
+	defer b.Close()
+	b.Settings().Set("save_backup", "bak")
+	b.Insert(0, "1")
+	if err := b.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("Didn't expect a backup of a new file")
+	}
+	b.Insert(1, "2")
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if s := readString(t, path+".bak"); s != "1" {
+		t.Errorf("Expected the backup to be %q, but got %q", "1", s)
+	}
+
+	b.Settings().Set("save_backup", "numbered")
+	for i, exp := range []string{"12", "123", "1234"} {
+		b.Insert(b.Size(), string('3'+rune(i)))
+		if err := b.Save(); err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(dir, "test.txt.~"+string('1'+rune(i))+"~")
+		if s := readString(t, name); s != exp {
+			t.Errorf("Expected %s to be %q, but got %q", name, exp, s)
+		}
+	}
+
+	b.Settings().Set("save_backup", "sideways")
+	if err := b.Save(); err == nil {
+		t.Error("Expected an error for an unknown kind of backup")
+	}
+}
+
+func TestSaveConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.txt")
+	ioutil.WriteFile(path, []byte("abc"), 0644)
+
+	b := NewBuffer()
+	defer b.Close()
+	if err := b.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	b.Insert(0, "x")
+
+	// Touching the file without changing it is fine
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if err := b.Save(); err != nil {
+		t.Errorf("Expected no conflict, but got %v", err)
+	}
+
+	ioutil.WriteFile(path, []byte("changed"), 0644)
+	later = later.Add(time.Hour)
+	os.Chtimes(path, later, later)
+	b.Insert(0, "y")
+	err = b.Save()
+	if ce, ok := err.(*ConflictError); !ok {
+		t.Errorf("Expected a ConflictError, but got %v", err)
+	} else if ce.Path != path || !ce.Current.Equal(later) {
+		t.Errorf("Unexpected error: %+v", ce)
+	}
+	if s := readString(t, path); s != "changed" {
+		t.Errorf("Expected the file to be left alone, but got %q", s)
+	}
+
+	// Saving somewhere else isn't a conflict
+	other := filepath.Join(dir, "other.txt")
+	if err := b.SaveFile(other); err != nil {
+		t.Error(err)
+	}
+	if err := b.ForceSaveFile(path); err != nil {
+		t.Error(err)
+	}
+	if s := readString(t, path); s != "yxabc" {
+		t.Errorf("Expected %q, but got %q", "yxabc", s)
+	}
+	if err := b.Save(); err != nil {
+		t.Errorf("Expected no conflict after saving, but got %v", err)
+	}
+}
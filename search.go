@@ -0,0 +1,276 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"io"
+	"regexp"
+)
+
+type (
+	// FindFlags change how the Find functions of a Buffer
+	// interpret their pattern and where they look.
+	FindFlags int
+
+	// Implemented by InnerBufferInterface implementations that can
+	// hand out the runes they store without copying them
+	chunker interface {
+		// Returns the runes stored contiguously from pos onwards.
+		// The returned slice must not be modified.
+		chunk(pos int) []rune
+	}
+
+	// An io.RuneReader over the runes from pos to end of an inner
+	// buffer, which reads them a chunk at a time
+	runeReader struct {
+		inner    InnerBufferInterface
+		pos, end int
+		chunk    []rune
+	}
+
+	// A compiled search pattern
+	finder struct {
+		re *regexp.Regexp
+		// The same pattern, but matching one more rune before it,
+		// after it, or both, indexed by the context bits. That lets
+		// searches that don't start at the beginning, or stop at the
+		// end, of the text see what comes before or after them.
+		// The rune after is captured by a group of its own, which
+		// is where the match of the pattern itself ends.
+		context [4]*regexp.Regexp
+	}
+)
+
+const (
+	// The pattern is plain text, rather than a regular expression
+	Literal FindFlags = 1 << iota
+	// Upper and lower case letters match each other
+	IgnoreCase
+	// Searches continue from the other end of the
+	// buffer if nothing is found before reaching its end
+	WrapAround
+	// Replacements are changed to the case of the text they
+	// replace, when it is all upper case, all lower case or
+	// starts with a capital letter
+	PreserveCase
+)
+
+// How many runes to read at a time when the inner
+// buffer can't hand them out directly
+const chunkSize = 4096
+
+// The Region returned when nothing is found
+var notFound = Region{-1, -1}
+
+// Which of the runes around a search is matched by a finder's pattern
+const (
+	contextBefore = 1 << iota
+	contextAfter
+)
+
+func (n *node) chunk(pos int) []rune {
+	if inner, off := n.find(pos); inner != nil && off < inner.leafLen() {
+		return inner.leafRunes(off)
+	}
+	return nil
+}
+
+func newRuneReader(inner InnerBufferInterface, r Region) *runeReader {
+	return &runeReader{inner: inner, pos: r.Begin(), end: r.End()}
+}
+
+func (r *runeReader) ReadRune() (rune, int, error) {
+	if len(r.chunk) == 0 {
+		if r.pos >= r.end {
+			return 0, 0, io.EOF
+		}
+		if c, ok := r.inner.(chunker); ok {
+			r.chunk = c.chunk(r.pos)
+		}
+		if len(r.chunk) == 0 {
+			r.chunk = r.inner.SubstrR(Region{r.pos, Min(r.end, r.pos+chunkSize)})
+		}
+		if len(r.chunk) > r.end-r.pos {
+			r.chunk = r.chunk[:r.end-r.pos]
+		}
+	}
+	ru := r.chunk[0]
+	r.chunk = r.chunk[1:]
+	r.pos++
+	// Claiming that every rune is a single byte, so that the
+	// offsets regexp reports are the text positions of the runes
+	return ru, 1, nil
+}
+
+func newFinder(pattern string, flags FindFlags) (*finder, error) {
+	if flags&Literal != 0 {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if flags&IgnoreCase != 0 {
+		pattern = "(?i)" + pattern
+	}
+	f := &finder{}
+	for c := range f.context {
+		p := `(?:` + pattern + `)`
+		if c&contextBefore != 0 {
+			p = `(?s:.)` + p
+		}
+		if c&contextAfter != 0 {
+			p += `((?s:.))`
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		f.context[c] = re
+	}
+	f.re = f.context[0]
+	return f, nil
+}
+
+// Returns the first match in the region r of the inner buffer as
+// pairs of text positions like regexp.FindSubmatchIndex, or nil if
+// there is none. Submatches are only included if asked for.
+func (f *finder) match(inner InnerBufferInterface, r Region, submatches bool) []int {
+	off, end, c := r.Begin(), r.End(), 0
+	if off > 0 {
+		off--
+		c |= contextBefore
+	}
+	if end < inner.Size() {
+		end++
+		c |= contextAfter
+	}
+	re := f.context[c]
+	rr := newRuneReader(inner, Region{off, end})
+	var m []int
+	if submatches || c&contextAfter != 0 {
+		m = re.FindReaderSubmatchIndex(rr)
+	} else {
+		m = re.FindReaderIndex(rr)
+	}
+	if m == nil {
+		return nil
+	}
+	for i := range m {
+		if m[i] >= 0 {
+			m[i] += off
+		}
+	}
+	if c&contextBefore != 0 {
+		// Skipping the extra rune matched before the pattern
+		m[0]++
+	}
+	if c&contextAfter != 0 {
+		// Ending where the extra rune matched after it starts
+		l := len(m) - 2
+		m[1] = m[l]
+		m = m[:l]
+	}
+	if !submatches {
+		m = m[:2]
+	}
+	return m
+}
+
+// Returns the first match in the region r of the inner buffer,
+// or notFound if there is none
+func (f *finder) find(inner InnerBufferInterface, r Region) Region {
+	if m := f.match(inner, r, false); m != nil {
+		return Region{m[0], m[1]}
+	}
+	return notFound
+}
+
+// Returns all the non-overlapping matches in the region r
+// of the inner buffer, as returned by match. As with
+// regexp.FindAll, an empty match right after the previous
+// match is skipped.
+func (f *finder) matchAll(inner InnerBufferInterface, r Region, submatches bool) (ret [][]int) {
+	prev := -1
+	for pos := r.Begin(); pos <= r.End(); {
+		m := f.match(inner, Region{pos, r.End()}, submatches)
+		if m == nil {
+			break
+		}
+		if m[0] != m[1] || m[0] != prev {
+			ret = append(ret, m)
+			prev = m[1]
+		}
+		pos = m[1]
+		if m[0] == m[1] {
+			pos++
+		}
+	}
+	return
+}
+
+// Returns all the matches in the region r of the inner buffer
+func (f *finder) findAll(inner InnerBufferInterface, r Region) (ret []Region) {
+	for _, m := range f.matchAll(inner, r, false) {
+		ret = append(ret, Region{m[0], m[1]})
+	}
+	return
+}
+
+// Returns the last match in the region r of the inner buffer,
+// or notFound if there is none. As with the other searches, the
+// pattern sees the text on either side of r, so for example $
+// doesn't match at the end of r unless the text ends there.
+func (f *finder) findBackward(inner InnerBufferInterface, r Region) Region {
+	// Looking in larger and larger regions before the end,
+	// so that the whole text needn't be searched
+	for size := chunkSize; ; size *= 2 {
+		start := Max(r.Begin(), r.End()-size)
+		if all := f.findAll(inner, Region{start, r.End()}); len(all) > 0 {
+			// A match at the start of the window might
+			// really begin before it, as with \w+
+			if last := all[len(all)-1]; last.Begin() != start || start == r.Begin() {
+				return last
+			}
+		} else if start == r.Begin() {
+			return notFound
+		}
+	}
+}
+
+func (b *buffer) Find(pattern string, start int, flags FindFlags) (Region, error) {
+	f, err := newFinder(pattern, flags)
+	if err != nil {
+		return notFound, err
+	}
+	inner := b.SerializedBuffer.snapshot()
+	size := inner.Size()
+	start = Clamp(0, size, start)
+	r := f.find(inner, Region{start, size})
+	if r == notFound && flags&WrapAround != 0 && start > 0 {
+		r = f.find(inner, Region{0, size})
+	}
+	return r, nil
+}
+
+func (b *buffer) FindAll(pattern string, flags FindFlags) ([]Region, error) {
+	f, err := newFinder(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	inner := b.SerializedBuffer.snapshot()
+	return f.findAll(inner, Region{0, inner.Size()}), nil
+}
+
+func (b *buffer) FindBackward(pattern string, start int, flags FindFlags) (Region, error) {
+	f, err := newFinder(pattern, flags)
+	if err != nil {
+		return notFound, err
+	}
+	inner := b.SerializedBuffer.snapshot()
+	size := inner.Size()
+	start = Clamp(0, size, start)
+	r := f.findBackward(inner, Region{0, start})
+	if r == notFound && flags&WrapAround != 0 && start < size {
+		r = f.findBackward(inner, Region{0, size})
+	}
+	return r, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRuneReader(t *testing.T) {
+	text := []rune(strings.Repeat("abcdefghij", 1000))
+	for _, bk := range backends {
+		inner := bk.new()
+		inner.InsertR(0, text[:5000])
+		inner.InsertR(5000, text[5000:])
+		for _, r := range []Region{{0, 0}, {0, 10000}, {3, 7}, {4000, 9000}} {
+			rr := newRuneReader(inner, r)
+			var got []rune
+			for {
+				ru, size, err := rr.ReadRune()
+				if err != nil {
+					break
+				}
+				if size != 1 {
+					t.Errorf("%s: Expected a size of 1, but got %d", bk.name, size)
+				}
+				got = append(got, ru)
+			}
+			if string(got) != string(text[r.A:r.B]) {
+				t.Errorf("%s: Unexpected runes read from %v", bk.name, r)
+			}
+		}
+		inner.Close()
+	}
+}
+
+func TestFind(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "Hello world\nhello there\nåäö hello\n")
+
+	tests := []struct {
+		pattern string
+		start   int
+		flags   FindFlags
+		exp     Region
+	}{
+		{"hello", 0, 0, Region{12, 17}},
+		{"hello", 0, IgnoreCase, Region{0, 5}},
+		{"hello", 13, 0, Region{28, 33}},
+		{"hello", 29, 0, Region{-1, -1}},
+		{"hello", 29, WrapAround, Region{12, 17}},
+		{"HELLO", 29, WrapAround | IgnoreCase, Region{0, 5}},
+		{"^hello", 13, 0, Region{-1, -1}},
+		{"(?m)^hello", 0, 0, Region{12, 17}},
+		{"(?m)^hello", 13, 0, Region{-1, -1}},
+		{`\bhello`, 1, IgnoreCase, Region{12, 17}},
+		{`\bello`, 1, 0, Region{-1, -1}},
+		{"h.llo", 0, 0, Region{12, 17}},
+		{"h.llo", 0, Literal, Region{-1, -1}},
+		{"ä", 0, 0, Region{25, 26}},
+		{"Ä", 0, IgnoreCase | Literal, Region{25, 26}},
+		{"", 5, 0, Region{5, 5}},
+	}
+	for i, test := range tests {
+		if r, err := b.Find(test.pattern, test.start, test.flags); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if r != test.exp {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.exp, r)
+		}
+	}
+
+	if _, err := b.Find("(", 0, 0); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if _, err := b.Find("(", 0, Literal); err != nil {
+		t.Errorf("Didn't expect an error for a literal pattern: %s", err)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "aaa\nab\n")
+
+	tests := []struct {
+		pattern string
+		flags   FindFlags
+		exp     []Region
+	}{
+		{"a", 0, []Region{{0, 1}, {1, 2}, {2, 3}, {4, 5}}},
+		{"aa", 0, []Region{{0, 2}}},
+		{"(?m)^a", 0, []Region{{0, 1}, {4, 5}}},
+		{"A+", IgnoreCase, []Region{{0, 3}, {4, 5}}},
+		{"b*", 0, []Region{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 6}, {7, 7}}},
+		{"x", 0, nil},
+	}
+	for i, test := range tests {
+		if r, err := b.FindAll(test.pattern, test.flags); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if !reflect.DeepEqual(r, test.exp) {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.exp, r)
+		}
+	}
+}
+
+func TestFindBackward(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "one two one two")
+
+	tests := []struct {
+		pattern string
+		start   int
+		flags   FindFlags
+		exp     Region
+	}{
+		{"one", 15, 0, Region{8, 11}},
+		{"one", 10, 0, Region{0, 3}},
+		{"one", 2, 0, Region{-1, -1}},
+		{"two", 2, WrapAround, Region{12, 15}},
+		{"ONE", 100, IgnoreCase, Region{8, 11}},
+	}
+	for i, test := range tests {
+		if r, err := b.FindBackward(test.pattern, test.start, test.flags); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if r != test.exp {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.exp, r)
+		}
+	}
+
+	// The text after the start is seen by the pattern, although
+	// no match may go past it
+	b.Insert(0, "baaac ")
+	tests = []struct {
+		pattern string
+		start   int
+		flags   FindFlags
+		exp     Region
+	}{
+		{"a$", 3, 0, Region{-1, -1}},
+		{`a\b`, 3, 0, Region{-1, -1}},
+		{`c\b`, 5, 0, Region{4, 5}},
+		{"a+", 3, 0, Region{1, 3}},
+		{"two$", b.Size(), 0, Region{18, 21}},
+	}
+	for i, test := range tests {
+		if r, err := b.FindBackward(test.pattern, test.start, test.flags); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		} else if r != test.exp {
+			t.Errorf("Test %d: Expected %v, but got %v", i, test.exp, r)
+		}
+	}
+
+	// Matches far from the start need several tries to find
+	b.Insert(0, "needle")
+	b.Insert(b.Size(), strings.Repeat(" ", 3*chunkSize))
+	if r, _ := b.FindBackward("needle", b.Size(), 0); r != (Region{0, 6}) {
+		t.Errorf("Expected the needle to be found, but got %v", r)
+	}
+
+	// And so do matches longer than the first try
+	b.Erase(0, b.Size())
+	b.Insert(0, "x "+strings.Repeat("w", 3*chunkSize)+" ")
+	if r, _ := b.FindBackward(`\w+`, b.Size(), 0); r != (Region{2, 2 + 3*chunkSize}) {
+		t.Errorf("Expected the whole word to be found, but got %v", r)
+	}
+}
+
+func TestFindAllEmptyMatches(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "baaac")
+
+	// Like regexp, not matching the empty string right after a match
+	exp := []Region{{0, 0}, {1, 4}, {5, 5}}
+	if r, _ := b.FindAll("a*", 0); !reflect.DeepEqual(r, exp) {
+		t.Errorf("Expected %v, but got %v", exp, r)
+	}
+}
@@ -49,6 +49,7 @@ func (s *SerializedBuffer) worker() {
 		}()
 	}
 	// Done processing all ops, so freeing the other resources here
+	s.inner.Close()
 	s.inner = nil
 	close(s.lockret)
 }
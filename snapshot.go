@@ -0,0 +1,130 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"github.com/limetext/log4go"
+)
+
+type (
+	// A Snapshot is a read-only, point-in-time view of a Buffer.
+	//
+	// It stays valid and unchanged while the buffer it was taken from
+	// keeps being modified, and unlike the Buffer itself it can be read
+	// from multiple goroutines without going through the buffer's
+	// serializing worker.
+	Snapshot interface {
+		// Returns the size of the snapshot
+		Size() int
+		// Returns the runes in the specified Region.
+		SubstrR(r Region) []rune
+		// Returns the string of the specified Region.
+		Substr(r Region) string
+		// Returns the rune at the given index
+		Index(int) rune
+		// Convert a text position into a row and column.
+		RowCol(point int) (row, col int)
+		// Inverse of #RowCol, converting a row and column
+		// into a text position.
+		TextPoint(row, col int) (i int)
+		// Returns the line region at the given offset
+		Line(offset int) Region
+		// Like #Line, but includes the line endings
+		FullLine(offset int) Region
+		// Returns the word region at the given text offset
+		Word(offset int) Region
+		// Returns the offset in UTF-8 bytes of the given text position
+		ByteOffset(point int) int
+		// Inverse of #ByteOffset. Offsets in the middle of a
+		// rune give the text position of that rune.
+		PointFromByte(offset int) int
+		// Returns the offset in UTF-16 code units of the given text position
+		UTF16Offset(point int) int
+		// Inverse of #UTF16Offset. Offsets in the middle of a
+		// surrogate pair give the text position of that rune.
+		PointFromUTF16(offset int) int
+		// Like #RowCol, but with the column counted in grapheme clusters
+		// rather than runes. Text positions inside of a grapheme cluster
+		// give the column of that cluster.
+		GraphemeRowCol(point int) (row, col int)
+		// Inverse of #GraphemeRowCol
+		GraphemeTextPoint(row, col int) int
+	}
+
+	// Implemented by InnerBufferInterface implementations that
+	// can hand out an immutable view of their contents cheaply.
+	snapshotter interface {
+		snapshot() InnerBufferInterface
+	}
+
+	snapshot struct {
+		inner InnerBufferInterface
+		words WordClassifier
+	}
+)
+
+// Returns an immutable copy of the given inner buffer. If the
+// implementation doesn't know how to do this cheaply itself the
+// contents are copied into a new rope.
+func snapshotOf(bi InnerBufferInterface) InnerBufferInterface {
+	if s, ok := bi.(snapshotter); ok {
+		return s.snapshot()
+	}
+	data := bi.SubstrR(Region{0, bi.Size()})
+	// SubstrR might return a slice aliasing the implementation's
+	// own storage, which would change under our feet.
+	return newNode(append([]rune(nil), data...))
+}
+
+func (s *SerializedBuffer) snapshot() InnerBufferInterface {
+	s.ops <- func() interface{} { return snapshotOf(s.inner) }
+	r := <-s.lockret
+	if r2, ok := r.(InnerBufferInterface); ok {
+		return r2
+	} else {
+		log4go.Error("Error: %v", r)
+		return newNode(nil)
+	}
+}
+
+func (b *buffer) Snapshot() Snapshot {
+	return &snapshot{b.SerializedBuffer.snapshot(), b.wordClassifier()}
+}
+
+func (s *snapshot) Size() int {
+	return s.inner.Size()
+}
+
+func (s *snapshot) SubstrR(r Region) []rune {
+	return s.inner.SubstrR(r)
+}
+
+func (s *snapshot) Substr(r Region) string {
+	return string(s.inner.SubstrR(r))
+}
+
+func (s *snapshot) Index(i int) rune {
+	return s.inner.Index(i)
+}
+
+func (s *snapshot) RowCol(point int) (row, col int) {
+	return s.inner.RowCol(point)
+}
+
+func (s *snapshot) TextPoint(row, col int) (i int) {
+	return s.inner.TextPoint(row, col)
+}
+
+func (s *snapshot) Line(offset int) Region {
+	return lineAt(s.inner, offset)
+}
+
+func (s *snapshot) FullLine(offset int) Region {
+	return fullLine(s, offset)
+}
+
+func (s *snapshot) Word(offset int) Region {
+	return word(s, offset, s.words)
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "Hello World!\nTest123123\nAbrakadabra\n")
+
+	s := b.Snapshot()
+	const exp = "Hello World!\nTest123123\nAbrakadabra\n"
+
+	b.Insert(5, ",")
+	b.Erase(0, 8)
+	b.Insert(b.Size(), "more\ntext")
+
+	if d := s.Substr(Region{0, s.Size()}); d != exp {
+		t.Errorf("Expected %q, but got %q", exp, d)
+	}
+	if s.Size() != len(exp) {
+		t.Errorf("Expected size %d, but got %d", len(exp), s.Size())
+	}
+	if r := s.Index(4); r != 'o' {
+		t.Errorf("Expected 'o', but got %q", r)
+	}
+	if r, c := s.RowCol(16); r != 1 || c != 3 {
+		t.Errorf("Expected 1, 3, but got %d, %d", r, c)
+	}
+	if p := s.TextPoint(2, 4); p != 28 {
+		t.Errorf("Expected 28, but got %d", p)
+	}
+	if l := s.Line(16); l != (Region{13, 23}) {
+		t.Errorf("Expected (13, 23), but got %v", l)
+	}
+	if l := s.FullLine(16); l != (Region{13, 24}) {
+		t.Errorf("Expected (13, 24), but got %v", l)
+	}
+	if w := s.Word(2); w != (Region{0, 5}) {
+		t.Errorf("Expected (0, 5), but got %v", w)
+	}
+}
+
+func TestSnapshotNaive(t *testing.T) {
+	var nb naiveBuffer
+	nb.InsertR(0, []rune("hello world"))
+	s := snapshotOf(&nb)
+	nb.Erase(0, 6)
+	nb.InsertR(0, []rune("HELLO "))
+	if d := string(s.SubstrR(Region{0, s.Size()})); d != "hello world" {
+		t.Errorf("Expected %q, but got %q", "hello world", d)
+	}
+}
+
+func TestSnapshotPathCopying(t *testing.T) {
+	defer func(m int) { merge = m }(merge)
+	for _, m := range merges {
+		merge = m
+		data := make([]rune, 1024)
+		fill(data)
+		n := &rebalancingNode{node: *newNodeEx(data, 8)}
+
+		type shot struct {
+			inner InnerBufferInterface
+			exp   string
+		}
+		var shots []shot
+		for i := 0; i < 200; i++ {
+			shots = append(shots, shot{n.snapshot(), n.Substr(Region{0, n.Size()})})
+			p := rand.Intn(n.Size() + 1)
+			if i%3 == 0 && p < n.Size() {
+				n.Erase(p, Min(n.Size()-p, 1+rand.Intn(64)))
+			} else {
+				in := make([]rune, 1+rand.Intn(64))
+				fill(in)
+				n.InsertR(p, in)
+			}
+		}
+		for i, s := range shots {
+			if d := string(s.inner.SubstrR(Region{0, s.inner.Size()})); d != s.exp {
+				t.Fatalf("%d, %d: snapshot was modified", m, i)
+			}
+		}
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	b := testbuffer()
+	defer b.Close()
+	s := b.Snapshot()
+	exp := s.Substr(Region{0, s.Size()})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 32; j++ {
+				p := rand.Intn(s.Size())
+				s.Line(p)
+				s.RowCol(p)
+			}
+			if d := s.Substr(Region{0, s.Size()}); d != exp {
+				t.Error("snapshot was modified")
+			}
+		}()
+	}
+	for i := 0; i < 256; i++ {
+		b.Insert(rand.Intn(b.Size()), "test")
+	}
+	wg.Wait()
+}
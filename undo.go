@@ -0,0 +1,212 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sync"
+)
+
+type (
+	// UndoStack records the Actions applied to a Buffer so that
+	// they can later be undone and redone.
+	//
+	// Undoing an action moves it onto the redo branch. The redo
+	// branch is discarded as soon as a new action is added.
+	UndoStack struct {
+		buffer   Buffer
+		actions  []Action
+		position int
+		maxDepth int
+		// The stack position at which the buffer was last marked
+		// clean, or -1 if that state is no longer reachable.
+		clean int
+		// The buffer's ChangeCount after the last operation performed
+		// through the stack.
+		changecount int
+		lock        sync.Mutex
+	}
+)
+
+// NewUndoStack returns a new UndoStack recording actions for the given
+// buffer. A maxDepth of 0 or less means the history is unlimited.
+//
+// The buffer is considered clean in its current state.
+func NewUndoStack(b Buffer, maxDepth int) *UndoStack {
+	return &UndoStack{
+		buffer:      b,
+		maxDepth:    maxDepth,
+		changecount: b.ChangeCount(),
+	}
+}
+
+// Buffer returns the buffer this stack is recording actions for
+func (us *UndoStack) Buffer() Buffer {
+	return us.buffer
+}
+
+// Add adds the action to the stack, without first executing
+// the action. Any actions on the redo branch are discarded.
+func (us *UndoStack) Add(a Action) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	us.add(a)
+}
+
+// AddExec executes the provided action and then adds
+// the action to the stack
+func (us *UndoStack) AddExec(a Action) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	a.Apply()
+	us.add(a)
+}
+
+func (us *UndoStack) add(a Action) {
+	if us.clean > us.position {
+		// The clean state is on the redo branch we're about to drop
+		us.clean = -1
+	}
+	us.actions = append(us.actions[:us.position], a)
+	us.position++
+	us.trim()
+	us.changecount = us.buffer.ChangeCount()
+}
+
+// Drops the oldest actions until the stack is within maxDepth
+func (us *UndoStack) trim() {
+	if us.maxDepth <= 0 || len(us.actions) <= us.maxDepth {
+		return
+	}
+	drop := len(us.actions) - us.maxDepth
+	if drop > us.position {
+		// Only applied actions can be forgotten from the bottom of
+		// the stack, the rest is cut from the end of the redo branch.
+		us.actions = us.actions[:len(us.actions)-(drop-us.position)]
+		if us.clean > len(us.actions) {
+			us.clean = -1
+		}
+		drop = us.position
+	}
+	us.actions = append(us.actions[:0], us.actions[drop:]...)
+	us.position -= drop
+	if us.clean >= 0 {
+		if us.clean -= drop; us.clean < 0 {
+			us.clean = -1
+		}
+	}
+}
+
+// Undo undoes the last applied action, moving it to the redo branch.
+// Returns false if there was nothing to undo.
+func (us *UndoStack) Undo() bool {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	if us.position == 0 {
+		return false
+	}
+	us.position--
+	us.actions[us.position].Undo()
+	us.changecount = us.buffer.ChangeCount()
+	return true
+}
+
+// Redo re-applies the most recently undone action.
+// Returns false if there was nothing to redo.
+func (us *UndoStack) Redo() bool {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	if us.position == len(us.actions) {
+		return false
+	}
+	us.actions[us.position].Apply()
+	us.position++
+	us.changecount = us.buffer.ChangeCount()
+	return true
+}
+
+// CanUndo returns whether there is an action to undo
+func (us *UndoStack) CanUndo() bool {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.position > 0
+}
+
+// CanRedo returns whether there is an action to redo
+func (us *UndoStack) CanRedo() bool {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.position < len(us.actions)
+}
+
+// Len returns the number of actions in the stack, including
+// those on the redo branch
+func (us *UndoStack) Len() int {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return len(us.actions)
+}
+
+// Position returns the number of actions that are currently applied
+func (us *UndoStack) Position() int {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.position
+}
+
+// Index returns the action at the given index of the stack
+func (us *UndoStack) Index(i int) Action {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.actions[i]
+}
+
+// MaxDepth returns the maximum number of actions kept in the stack
+func (us *UndoStack) MaxDepth() int {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.maxDepth
+}
+
+// SetMaxDepth sets the maximum number of actions kept in the stack,
+// dropping the oldest actions if there currently are more than that.
+// A value of 0 or less means the history is unlimited.
+func (us *UndoStack) SetMaxDepth(depth int) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	us.maxDepth = depth
+	us.trim()
+}
+
+// Clear removes all actions from the stack without undoing them
+func (us *UndoStack) Clear() {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	us.actions = nil
+	if us.clean != us.position {
+		us.clean = -1
+	} else {
+		us.clean = 0
+	}
+	us.position = 0
+}
+
+// MarkClean marks the buffer's current state as clean, which is
+// typically done after it has been saved
+func (us *UndoStack) MarkClean() {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	us.clean = us.position
+	us.changecount = us.buffer.ChangeCount()
+}
+
+// IsDirty returns whether the buffer differs from the state it
+// was in when last marked clean. Undoing back to that state makes
+// the buffer clean again, but any modification made to the buffer
+// without going through the stack makes it dirty.
+func (us *UndoStack) IsDirty() bool {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	return us.buffer.ChangeCount() != us.changecount || us.clean != us.position
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+func TestUndoStack(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	us := NewUndoStack(b, 0)
+
+	check := func(exp string) {
+		if d := b.Substr(Region{0, b.Size()}); d != exp {
+			t.Errorf("Expected %q, but got %q", exp, d)
+		}
+	}
+
+	us.AddExec(NewInsertAction(b, 0, "hello"))
+	us.AddExec(NewInsertAction(b, 5, " world"))
+	us.AddExec(NewEraseAction(b, Region{0, 1}))
+	check("ello world")
+
+	if !us.Undo() {
+		t.Error("Expected to be able to undo")
+	}
+	check("hello world")
+	us.Undo()
+	check("hello")
+	if !us.CanRedo() {
+		t.Error("Expected to be able to redo")
+	}
+	us.Redo()
+	check("hello world")
+
+	// A new action discards the redo branch
+	us.AddExec(NewInsertAction(b, 0, ">"))
+	check(">hello world")
+	if us.Redo() {
+		t.Error("Expected the redo branch to be discarded")
+	}
+	if l := us.Len(); l != 3 {
+		t.Errorf("Expected 3 actions, but got %d", l)
+	}
+
+	for us.Undo() {
+	}
+	check("")
+	if us.CanUndo() {
+		t.Error("Expected nothing to undo")
+	}
+	for us.Redo() {
+	}
+	check(">hello world")
+}
+
+func TestUndoStackMaxDepth(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	us := NewUndoStack(b, 2)
+
+	for i := 0; i < 5; i++ {
+		us.AddExec(NewInsertAction(b, b.Size(), "a"))
+	}
+	if l := us.Len(); l != 2 {
+		t.Errorf("Expected 2 actions, but got %d", l)
+	}
+	for us.Undo() {
+	}
+	if d := b.Substr(Region{0, b.Size()}); d != "aaa" {
+		t.Errorf("Expected %q, but got %q", "aaa", d)
+	}
+	us.SetMaxDepth(1)
+	if l, p := us.Len(), us.Position(); l != 1 || p != 0 {
+		t.Errorf("Expected 1 action at position 0, but got %d at %d", l, p)
+	}
+}
+
+func TestUndoStackDirty(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "hello")
+	us := NewUndoStack(b, 0)
+
+	if us.IsDirty() {
+		t.Error("Expected a new stack to be clean")
+	}
+	us.AddExec(NewInsertAction(b, 5, " world"))
+	if !us.IsDirty() {
+		t.Error("Expected the stack to be dirty after an edit")
+	}
+	us.MarkClean()
+	if us.IsDirty() {
+		t.Error("Expected the stack to be clean after MarkClean")
+	}
+	us.Undo()
+	if !us.IsDirty() {
+		t.Error("Expected the stack to be dirty after undo")
+	}
+	us.Redo()
+	if us.IsDirty() {
+		t.Error("Expected the stack to be clean after redoing to the clean state")
+	}
+
+	b.Insert(0, "x")
+	if !us.IsDirty() {
+		t.Error("Expected an edit outside of the stack to make it dirty")
+	}
+	b.Erase(0, 1)
+	us.MarkClean()
+
+	// Dropping the redo branch containing the clean state
+	us.Undo()
+	us.AddExec(NewInsertAction(b, 0, "x"))
+	us.Undo()
+	if !us.IsDirty() {
+		t.Error("Expected the clean state to be unreachable")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// UndoTree is a tree shaped undo history.
+	//
+	// Unlike the UndoStack, undoing a number of actions and then
+	// adding a new one doesn't discard the undone actions. Instead
+	// the new action starts a new branch from the current state,
+	// and all branches remain reachable through #Goto, #Earlier
+	// and #Later.
+	UndoTree struct {
+		root    *UndoNode
+		current *UndoNode
+		// All the nodes in the tree, indexed by sequence number
+		nodes []*UndoNode
+		now   func() time.Time
+		lock  sync.Mutex
+	}
+
+	// UndoNode is a single state in an UndoTree, reached by applying
+	// the node's action to the state of its parent.
+	UndoNode struct {
+		seq      int
+		time     time.Time
+		action   *CompositeAction
+		parent   *UndoNode
+		children []*UndoNode
+		// Index of the child Redo will move to
+		redo int
+	}
+)
+
+var (
+	ErrUndoStateNotFound = fmt.Errorf("No such undo state")
+)
+
+// NewUndoTree returns a new UndoTree whose root represents the state
+// of the buffer at the time of creation.
+func NewUndoTree() *UndoTree {
+	return newUndoTree(time.Now)
+}
+
+func newUndoTree(now func() time.Time) *UndoTree {
+	root := &UndoNode{time: now()}
+	return &UndoTree{
+		root:    root,
+		current: root,
+		nodes:   []*UndoNode{root},
+		now:     now,
+	}
+}
+
+// Seq returns the sequence number of the node. The root has sequence
+// number 0, and every added node gets the next number in order.
+func (n *UndoNode) Seq() int {
+	return n.seq
+}
+
+// Time returns the time at which the node was added to the tree
+func (n *UndoNode) Time() time.Time {
+	return n.time
+}
+
+// Action returns the action leading from the parent's state to this
+// node's state. It is nil for the root.
+func (n *UndoNode) Action() *CompositeAction {
+	return n.action
+}
+
+// Parent returns the parent of the node, or nil for the root
+func (n *UndoNode) Parent() *UndoNode {
+	return n.parent
+}
+
+// Children returns a copy of the node's child nodes, in the order
+// they were added
+func (n *UndoNode) Children() []*UndoNode {
+	ret := make([]*UndoNode, len(n.children))
+	copy(ret, n.children)
+	return ret
+}
+
+func (n *UndoNode) depth() (d int) {
+	for ; n.parent != nil; n = n.parent {
+		d++
+	}
+	return
+}
+
+// Add adds the action to the tree as a child of the current state,
+// without first executing the action, and makes it the current state.
+func (t *UndoTree) Add(a *CompositeAction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.add(a)
+}
+
+// AddExec executes the provided action and then adds
+// the action to the tree
+func (t *UndoTree) AddExec(a *CompositeAction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	a.Apply()
+	t.add(a)
+}
+
+func (t *UndoTree) add(a *CompositeAction) {
+	n := &UndoNode{
+		seq:    len(t.nodes),
+		time:   t.now(),
+		action: a,
+		parent: t.current,
+	}
+	t.nodes = append(t.nodes, n)
+	t.current.redo = len(t.current.children)
+	t.current.children = append(t.current.children, n)
+	t.current = n
+}
+
+// Undo undoes the action of the current state, moving to its parent.
+// Returns false if already at the root.
+func (t *UndoTree) Undo() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.current.parent == nil {
+		return false
+	}
+	t.up()
+	return true
+}
+
+// Redo moves to the child of the current state that was most recently
+// added or visited, applying its action. Returns false if the current
+// state has no children.
+func (t *UndoTree) Redo() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.current.children) == 0 {
+		return false
+	}
+	t.down(t.current.children[t.current.redo])
+	return true
+}
+
+func (t *UndoTree) up() {
+	t.current.action.Undo()
+	t.current = t.current.parent
+}
+
+func (t *UndoTree) down(child *UndoNode) {
+	for i, c := range t.current.children {
+		if c == child {
+			t.current.redo = i
+		}
+	}
+	child.action.Apply()
+	t.current = child
+}
+
+// Current returns the node of the current state
+func (t *UndoTree) Current() *UndoNode {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.current
+}
+
+// Root returns the root node of the tree
+func (t *UndoTree) Root() *UndoNode {
+	return t.root
+}
+
+// Len returns the number of states in the tree, including the root
+func (t *UndoTree) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.nodes)
+}
+
+// Node returns the node with the given sequence number, or nil
+// if there is no such node
+func (t *UndoTree) Node(seq int) *UndoNode {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if seq < 0 || seq >= len(t.nodes) {
+		return nil
+	}
+	return t.nodes[seq]
+}
+
+// Branches returns the tip of every branch in the tree, that is
+// every node without children, ordered by sequence number.
+func (t *UndoTree) Branches() (ret []*UndoNode) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, n := range t.nodes {
+		if len(n.children) == 0 {
+			ret = append(ret, n)
+		}
+	}
+	return
+}
+
+// Goto undoes and redoes actions as needed to move to the state
+// with the given sequence number.
+func (t *UndoTree) Goto(seq int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if seq < 0 || seq >= len(t.nodes) {
+		return ErrUndoStateNotFound
+	}
+	t.goTo(t.nodes[seq])
+	return nil
+}
+
+func (t *UndoTree) goTo(target *UndoNode) {
+	// Walk up from both ends until the common ancestor is found,
+	// remembering the path down to the target.
+	var path []*UndoNode
+	cd, td := t.current.depth(), target.depth()
+	for ; td > cd; td-- {
+		path = append(path, target)
+		target = target.parent
+	}
+	for ; cd > td; cd-- {
+		t.up()
+	}
+	for t.current != target {
+		t.up()
+		path = append(path, target)
+		target = target.parent
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		t.down(path[i])
+	}
+}
+
+// Earlier moves to the state the buffer was in the given duration
+// before the time of the current state.
+func (t *UndoTree) Earlier(d time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.goTo(t.at(t.current.time.Add(-d)))
+}
+
+// Later moves to the state the buffer was in the given duration
+// after the time of the current state.
+func (t *UndoTree) Later(d time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.goTo(t.at(t.current.time.Add(d)))
+}
+
+// Returns the most recent state added at or before the given time
+func (t *UndoTree) at(when time.Time) *UndoNode {
+	i := sort.Search(len(t.nodes), func(i int) bool {
+		return t.nodes[i].time.After(when)
+	})
+	if i == 0 {
+		return t.root
+	}
+	return t.nodes[i-1]
+}
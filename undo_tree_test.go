@@ -0,0 +1,100 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUndoTree(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+
+	clock := time.Unix(0, 0)
+	ut := newUndoTree(func() time.Time { return clock })
+	insert := func(point int, value string) {
+		clock = clock.Add(time.Second)
+		ut.AddExec(&CompositeAction{[]Action{NewInsertAction(b, point, value)}})
+	}
+	check := func(exp string) {
+		t.Helper()
+		if d := b.Substr(Region{0, b.Size()}); d != exp {
+			t.Errorf("Expected %q, but got %q", exp, d)
+		}
+	}
+
+	insert(0, "one")  // 1
+	insert(3, " two") // 2
+	ut.Undo()         // back at 1
+	insert(3, " 2")   // 3
+	insert(5, " 3")   // 4
+	check("one 2 3")
+
+	if l := ut.Len(); l != 5 {
+		t.Errorf("Expected 5 states, but got %d", l)
+	}
+	br := ut.Branches()
+	if len(br) != 2 || br[0].Seq() != 2 || br[1].Seq() != 4 {
+		t.Errorf("Unexpected branches: %v", br)
+	}
+
+	// The old branch is still reachable
+	if err := ut.Goto(2); err != nil {
+		t.Fatal(err)
+	}
+	check("one two")
+	if s := ut.Current().Seq(); s != 2 {
+		t.Errorf("Expected to be at 2, but at %d", s)
+	}
+	if err := ut.Goto(4); err != nil {
+		t.Fatal(err)
+	}
+	check("one 2 3")
+	if err := ut.Goto(5); err != ErrUndoStateNotFound {
+		t.Errorf("Expected %v, but got %v", ErrUndoStateNotFound, err)
+	}
+
+	// Redo follows the most recently visited branch
+	ut.Goto(2)
+	ut.Undo()
+	ut.Redo()
+	check("one two")
+	ut.Goto(0)
+	check("")
+	if ut.Undo() {
+		t.Error("Expected to not be able to undo past the root")
+	}
+	ut.Redo()
+	ut.Redo()
+	check("one two")
+}
+
+func TestUndoTreeTime(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+
+	clock := time.Unix(0, 0)
+	ut := newUndoTree(func() time.Time { return clock })
+	for i, s := range []string{"a", "b", "c", "d"} {
+		clock = clock.Add(10 * time.Second)
+		ut.AddExec(&CompositeAction{[]Action{NewInsertAction(b, i, s)}})
+	}
+	check := func(exp string) {
+		t.Helper()
+		if d := b.Substr(Region{0, b.Size()}); d != exp {
+			t.Errorf("Expected %q, but got %q", exp, d)
+		}
+	}
+
+	ut.Earlier(20 * time.Second)
+	check("ab")
+	ut.Earlier(15 * time.Second)
+	check("")
+	ut.Later(25 * time.Second)
+	check("ab")
+	ut.Later(time.Hour)
+	check("abcd")
+}
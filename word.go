@@ -0,0 +1,57 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	// WordClassifier decides where words begin and end,
+	// for Buffer.Word and Buffer.WordR.
+	WordClassifier interface {
+		// Returns whether the rune separates words,
+		// rather than being a part of one
+		IsSeparator(r rune) bool
+	}
+
+	// WordSeparators is a WordClassifier treating the runes in
+	// the string as separators. Non-ASCII punctuation and spaces,
+	// other than connectors such as '‿', are separators too, so
+	// that text in any script is split up the way it is written.
+	WordSeparators string
+)
+
+// The separators used unless the "word_separators" setting says otherwise
+const DefaultWordSeparators = "./\\()\"'-:,.;<>~!@#$%^&*|+=[]{}`~?"
+
+// Name of the setting holding the word separators,
+// which is used unless a WordClassifier has been set
+const wordSeparatorsSetting = "word_separators"
+
+func (s WordSeparators) IsSeparator(r rune) bool {
+	if r < utf8.RuneSelf {
+		return strings.ContainsRune(string(s), r)
+	}
+	return (unicode.IsPunct(r) && !unicode.Is(unicode.Pc, r)) || unicode.IsSpace(r) || strings.ContainsRune(string(s), r)
+}
+
+func (b *buffer) SetWordClassifier(wc WordClassifier) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.words = wc
+}
+
+func (b *buffer) wordClassifier() WordClassifier {
+	b.lock.Lock()
+	wc := b.words
+	b.lock.Unlock()
+	if wc != nil {
+		return wc
+	}
+	return WordSeparators(b.Settings().String(wordSeparatorsSetting, DefaultWordSeparators))
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Fredrik Ehnbom
+// Use of this source code is governed by a 2-clause
+// BSD-style license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sync"
+	"testing"
+	"unicode"
+)
+
+type digitClassifier struct{}
+
+func (digitClassifier) IsSeparator(r rune) bool {
+	return unicode.IsDigit(r)
+}
+
+func TestWordSeparators(t *testing.T) {
+	tests := []struct {
+		r   rune
+		exp bool
+	}{
+		{'a', false},
+		{'_', false},
+		{'.', true},
+		{'-', true},
+		{'д', false},
+		{'日', false},
+		{'。', true},
+		{'、', true},
+		{'«', true},
+		{'　', true},
+		{' ', true},
+		{'‿', false},
+		{'＿', false},
+	}
+	for i, test := range tests {
+		if s := WordSeparators(DefaultWordSeparators).IsSeparator(test.r); s != test.exp {
+			t.Errorf("Test %d: Expected %q to be a separator: %v", i, test.r, test.exp)
+		}
+	}
+	if WordSeparators("").IsSeparator('.') {
+		t.Error("Didn't expect '.' to be a separator")
+	}
+}
+
+func TestWordClassifier(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "foo-bar привет_мир 日本語。テスト x1y\n")
+
+	tests := []struct {
+		offset int
+		exp    string
+	}{
+		{1, "foo"},
+		{5, "bar"},
+		{10, "привет_мир"},
+		{20, "日本語"},
+		{25, "テスト"},
+	}
+	for i, test := range tests {
+		if w := b.Substr(b.Word(test.offset)); w != test.exp {
+			t.Errorf("Test %d: Expected %q, but got %q", i, test.exp, w)
+		}
+	}
+
+	b.Settings().Set("word_separators", "_")
+	if w := b.Substr(b.Word(1)); w != "foo-bar" {
+		t.Errorf("Expected %q, but got %q", "foo-bar", w)
+	}
+	if w := b.Substr(b.Word(10)); w != "привет" {
+		t.Errorf("Expected %q, but got %q", "привет", w)
+	}
+
+	// Snapshots use the classifier of the buffer when they were taken
+	s := b.Snapshot()
+	b.SetWordClassifier(digitClassifier{})
+	if w := b.Substr(b.Word(28)); w != "x" {
+		t.Errorf("Expected %q, but got %q", "x", w)
+	}
+	if w := s.Substr(s.Word(28)); w != "x1y" {
+		t.Errorf("Expected the snapshot to find %q, but got %q", "x1y", w)
+	}
+
+	b.SetWordClassifier(nil)
+	if w := b.Substr(b.Word(28)); w != "x1y" {
+		t.Errorf("Expected %q, but got %q", "x1y", w)
+	}
+}
+
+func TestWordConcurrent(t *testing.T) {
+	b := NewBuffer()
+	defer b.Close()
+	b.Insert(0, "foo-bar x1y")
+
+	// Each call must give what either classifier gives on its own
+	offsets := []int{1, 8}
+	var digits, separators []Region
+	b.SetWordClassifier(digitClassifier{})
+	for _, o := range offsets {
+		digits = append(digits, b.Word(o))
+	}
+	b.SetWordClassifier(nil)
+	for _, o := range offsets {
+		separators = append(separators, b.Word(o))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for k, o := range offsets {
+					if w := b.Word(o); w != digits[k] && w != separators[k] {
+						t.Errorf("Expected %v or %v, but got %v", digits[k], separators[k], w)
+					}
+				}
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		if j%2 == 0 {
+			b.SetWordClassifier(digitClassifier{})
+		} else {
+			b.SetWordClassifier(nil)
+		}
+		b.Settings().Set("word_separators", DefaultWordSeparators)
+	}
+	wg.Wait()
+}